@@ -0,0 +1,215 @@
+//go:build !js
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// run converts the compose file named in args (or stdin) and writes the
+// resulting jobs. A single job is printed to stdout unless an output directory
+// is given; multiple jobs are always written as separate files. The
+// to-compose subcommand converts in the other direction.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "to-compose" {
+		return runToCompose(args[1:], stdin, stdout, stderr)
+	}
+
+	flags := flag.NewFlagSet("compose2nomad", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: compose2nomad [flags] [docker-compose.yml]")
+		fmt.Fprintln(flags.Output(), "       compose2nomad to-compose [flags] [job.nomad.hcl | job.json]")
+		flags.PrintDefaults()
+	}
+	jobName := flags.String("job", "", "name of the generated job (defaults to the compose project name)")
+	split := flags.String("split", "", "emit one job per \"service\" or per x-nomad-job \"label\" value")
+	format := flags.String("format", "", "output format: HCL (default), \"json\" for the Nomad API job payload, parsed from the HCL without its notes, or \"pack\" for a Nomad Pack")
+	driver := flags.String("driver", "", "task driver running the services: \"docker\" (default) or \"podman\"")
+	maxKillTimeout := flags.Duration("max-kill-timeout", 0, "max_kill_timeout of the Nomad clients; fail when a stop_grace_period exceeds it")
+	restartAttempts := flags.Int("restart-attempts", 0, "restart attempts of services with a compose restart policy (default 3)")
+	restartInterval := flags.Duration("restart-interval", 0, "interval the restart attempts are counted in (default 1m)")
+	restartDelay := flags.Duration("restart-delay", 0, "delay before a task is restarted (default 15s)")
+	deployLabels := flags.String("deploy-labels", "", "write deploy labels to group meta (default) or as service \"tags\"")
+	discovery := flags.String("discovery", "", "resolve references to other services through \"nomad\" or \"consul\" template lookups or \"dns\" names")
+	connect := flags.Bool("connect", false, "add the services to the Consul Connect service mesh")
+	provenance := flags.Bool("provenance", false, "record the converter version, compose file name and input hash in the job meta")
+	lint := flags.Bool("lint", false, "check the generated jobs with Nomad's jobspec parser and job validation")
+	varImageTags := flags.Bool("var-image-tags", false, "lift image tags into HCL2 variables")
+	varCounts := flags.Bool("var-counts", false, "lift group counts into HCL2 variables")
+	varDatacenters := flags.Bool("var-datacenters", false, "lift the job datacenters into an HCL2 variable")
+	varEnv := flags.String("var-env", "", "lift env values whose names match this regular expression into HCL2 variables")
+	outDir := flags.String("out", "", "directory to write job files to (defaults to stdout for a single job)")
+	cniNetworks := mappingFlag{}
+	flags.Var(cniNetworks, "cni-network", "map a compose network to a CNI network as \"compose=cni\" (repeatable)")
+	placementAttributes := mappingFlag{}
+	flags.Var(placementAttributes, "placement-attribute", "map a Swarm placement attribute to a Nomad node attribute as \"node.role=${node.class}\" (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+
+	files, err := converter.ConvertToNomadJobs(string(input), converter.Options{
+		JobName:             *jobName,
+		Split:               converter.SplitMode(*split),
+		Format:              converter.OutputFormat(*format),
+		Lint:                *lint,
+		Driver:              converter.TaskDriver(*driver),
+		MaxKillTimeout:      *maxKillTimeout,
+		DeployLabels:        converter.DeployLabelTarget(*deployLabels),
+		Provenance:          *provenance,
+		SourceName:          sourceName(flags.Arg(0)),
+		CNINetworks:         cniNetworks,
+		Connect:             *connect,
+		Discovery:           converter.DiscoveryMode(*discovery),
+		PlacementAttributes: placementAttributes,
+		Restart: converter.RestartOptions{
+			Attempts: *restartAttempts,
+			Interval: *restartInterval,
+			Delay:    *restartDelay,
+		},
+		Variables: converter.VariableOptions{
+			ImageTags:   *varImageTags,
+			Counts:      *varCounts,
+			Datacenters: *varDatacenters,
+			EnvPattern:  *varEnv,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if *outDir == "" && len(files) == 1 {
+		for _, content := range files {
+			_, err := io.WriteString(stdout, content)
+			return err
+		}
+	}
+	if *outDir == "" {
+		*outDir = "."
+	}
+	return writeFiles(*outDir, files, stdout)
+}
+
+// runToCompose converts the Nomad job named in args (or stdin) back into a
+// compose file, reporting the Nomad features it cannot represent on stderr.
+func runToCompose(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("compose2nomad to-compose", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: compose2nomad to-compose [flags] [job.nomad.hcl | job.json]")
+		flags.PrintDefaults()
+	}
+	outFile := flags.String("out", "", "file to write the compose file to (defaults to stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	fileName := flags.Arg(0)
+	if fileName == "" || fileName == "-" {
+		fileName = "stdin"
+	}
+
+	yamlOutput, diags := converter.ConvertNomadToCompose(fileName, string(input))
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, diag := range diags {
+		fmt.Fprintf(stderr, "Warning: %s\n", diag.Detail)
+	}
+
+	if *outFile == "" {
+		_, err := io.WriteString(stdout, yamlOutput)
+		return err
+	}
+	if err := os.WriteFile(*outFile, []byte(yamlOutput), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "Wrote", *outFile)
+	return nil
+}
+
+// mappingFlag collects repeated "key=value" flags into a map.
+type mappingFlag map[string]string
+
+func (m mappingFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (m mappingFlag) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || key == "" || value == "" {
+		return fmt.Errorf("expected key=value, got %q", pair)
+	}
+	m[key] = value
+	return nil
+}
+
+// sourceName returns the file name of the compose file at path, or an empty
+// name when it is read from stdin.
+func sourceName(path string) string {
+	if path == "" || path == "-" {
+		return ""
+	}
+	return filepath.Base(path)
+}
+
+// readInput reads the compose file at path, or stdin when path is empty or "-".
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeFiles writes every generated file below dir and reports each path on stdout.
+func writeFiles(dir string, files map[string]string, stdout io.Writer) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// File names come from the compose file, so refuse any that would
+		// be written outside dir.
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("refusing to write %s outside %s", name, dir)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(files[name]), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "Wrote", path)
+	}
+	return nil
+}
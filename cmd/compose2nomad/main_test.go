@@ -0,0 +1,41 @@
+//go:build !js
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"shop.nomad.hcl":                 "job",
+		"shop/templates/shop.nomad.tpl":  "template",
+		"shop/templates/../metadata.hcl": "metadata",
+	}
+	if err := writeFiles(dir, files, io.Discard); err != nil {
+		t.Fatalf("writeFiles failed: %v", err)
+	}
+	for _, name := range []string{"shop.nomad.hcl", "shop/templates/shop.nomad.tpl", "shop/metadata.hcl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+}
+
+func TestWriteFiles_OutsideDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"../escape.nomad.hcl", "shop/../../escape.nomad.hcl", "/tmp/escape.nomad.hcl"} {
+		err := writeFiles(filepath.Join(dir, "out"), map[string]string{name: "job"}, io.Discard)
+		if err == nil || !strings.Contains(err.Error(), "refusing to write") {
+			t.Errorf("Expected %s to be refused, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.nomad.hcl")); err == nil {
+		t.Errorf("A file was written outside the output directory")
+	}
+}
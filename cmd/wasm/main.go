@@ -43,9 +43,44 @@ func convert(this js.Value, args []js.Value) interface{} {
 	return promiseConstructor.New(handler)
 }
 
+// convertJobs wraps the internal converter's ConvertToNomadJobs function for JS
+// interop. It returns a Promise that resolves with an object mapping each job
+// file name to its HCL, for compose files whose services need several jobs.
+func convertJobs(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		errorConstructor := js.Global().Get("Error")
+		return errorConstructor.New("Invalid number of arguments. Expected 1 (yamlInput string).")
+	}
+	yamlInput := args[0].String()
+
+	handler := js.FuncOf(func(this js.Value, pArgs []js.Value) interface{} {
+		resolve := pArgs[0]
+		reject := pArgs[1]
+
+		go func() {
+			files, err := converter.ConvertToNomadJobs(yamlInput, converter.Options{})
+			if err != nil {
+				errorConstructor := js.Global().Get("Error")
+				reject.Invoke(errorConstructor.New(err.Error()))
+				return
+			}
+			jobs := make(map[string]interface{}, len(files))
+			for fileName, content := range files {
+				jobs[fileName] = content
+			}
+			resolve.Invoke(js.ValueOf(jobs))
+		}()
+		return nil
+	})
+
+	promiseConstructor := js.Global().Get("Promise")
+	return promiseConstructor.New(handler)
+}
+
 func main() {
 	c := make(chan struct{}, 0)
 	fmt.Println("Go WASM Initialized (compose2nomad)")
 	js.Global().Set("convertToNomad", js.FuncOf(convert))
+	js.Global().Set("convertToNomadJobs", js.FuncOf(convertJobs))
 	<-c // Keep the program alive
 }
\ No newline at end of file
@@ -0,0 +1,253 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// meshUpstream is a service a group reaches through its Connect sidecar.
+type meshUpstream struct {
+	destination   string
+	port          string // Container port the destination is registered on
+	localBindPort int
+}
+
+// groupMesh is the Consul Connect configuration of a group.
+type groupMesh struct {
+	port      string // Container port the group's service is registered on, empty without one
+	upstreams []meshUpstream
+	notes     []string
+}
+
+// meshPlan holds the Connect configuration of every group in the mesh. It is
+// nil when Connect is disabled.
+type meshPlan struct {
+	groups  map[string]*groupMesh
+	ownerOf map[string]string // Group of every service
+}
+
+// group returns the Connect configuration of the named group, or nil when
+// the group is not in the mesh.
+func (m *meshPlan) group(name string) *groupMesh {
+	if m == nil {
+		return nil
+	}
+	return m.groups[name]
+}
+
+// meshReference is a dependency of a group on another group, and the port it
+// is referenced with, if any.
+type meshReference struct {
+	destination string
+	port        string
+}
+
+// planMesh derives the Connect upstreams of every group from the
+// `depends_on` and `links` of its services and from the hostnames of other
+// services referenced in their environment. Each destination is registered on
+// its first container port, or the port other services reference it with,
+// and bound to the same local port in the groups reaching it unless that
+// port is taken.
+func planMesh(dc *dockercompose.DockerCompose, jobs []*jobPlan, ownerOf map[string]string) *meshPlan {
+	var groups []*groupPlan
+	for _, job := range jobs {
+		groups = append(groups, job.groups...)
+	}
+
+	references := make(map[string][]meshReference)
+	referencedPorts := make(map[string][]string)
+	for _, group := range groups {
+		for _, memberName := range group.members() {
+			member := dc.Services[memberName]
+			for _, dependency := range append(dependsOnNames(member.DependsOn), linkTargets(member.Links)...) {
+				if owner, ok := ownerOf[dependency]; ok {
+					references[group.name] = append(references[group.name], meshReference{destination: owner})
+				}
+			}
+			env := parseKeyValues(member.Environment)
+			hosts := serviceHosts(dc, ownerOf, member)
+			for _, key := range sortedKeys(env) {
+				for _, ref := range findHostReferences(env[key], hosts) {
+					destination := hosts[ref.host]
+					references[group.name] = append(references[group.name], meshReference{destination: destination, port: ref.port})
+					if ref.port != "" {
+						referencedPorts[destination] = append(referencedPorts[destination], ref.port)
+					}
+				}
+			}
+		}
+	}
+
+	ports := make(map[string]string)
+	for _, group := range groups {
+		for _, memberName := range group.members() {
+			for _, spec := range dc.Services[memberName].Ports {
+				if port := containerPortOf(spec); port != "" && ports[group.name] == "" {
+					ports[group.name] = port
+				}
+			}
+		}
+		if ports[group.name] == "" && len(referencedPorts[group.name]) > 0 {
+			candidates := referencedPorts[group.name]
+			sort.Slice(candidates, func(i, j int) bool { return portNumber(candidates[i]) < portNumber(candidates[j]) })
+			ports[group.name] = candidates[0]
+		}
+	}
+
+	plan := &meshPlan{groups: make(map[string]*groupMesh), ownerOf: ownerOf}
+	for _, group := range groups {
+		mesh := &groupMesh{port: ports[group.name]}
+		usedPorts := make(map[int]bool)
+		for _, memberName := range group.members() {
+			for _, spec := range dc.Services[memberName].Ports {
+				usedPorts[portNumber(containerPortOf(spec))] = true
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, ref := range references[group.name] {
+			if ref.destination == group.name {
+				continue
+			}
+			port := ports[ref.destination]
+			if ref.port != "" && port != "" && ref.port != port {
+				mesh.notes = append(mesh.notes, fmt.Sprintf("'%s' is referenced on port %s, but the mesh only reaches it on port %s.", ref.destination, ref.port, port))
+			}
+			if seen[ref.destination] {
+				continue
+			}
+			seen[ref.destination] = true
+			if port == "" {
+				mesh.notes = append(mesh.notes, fmt.Sprintf("The port of upstream '%s' is unknown; publish it or reference it with a port to add it to the mesh.", ref.destination))
+				continue
+			}
+			bindPort := portNumber(port)
+			for usedPorts[bindPort] {
+				bindPort++
+			}
+			usedPorts[bindPort] = true
+			if bindPort != portNumber(port) {
+				mesh.notes = append(mesh.notes, fmt.Sprintf("Upstream '%s' is bound to local port %d, as port %s is taken; references to it without a port must be updated.", ref.destination, bindPort, port))
+			}
+			mesh.upstreams = append(mesh.upstreams, meshUpstream{destination: ref.destination, port: port, localBindPort: bindPort})
+		}
+		sort.Slice(mesh.upstreams, func(i, j int) bool { return mesh.upstreams[i].destination < mesh.upstreams[j].destination })
+
+		if mesh.port != "" || len(mesh.upstreams) > 0 {
+			plan.groups[group.name] = mesh
+		}
+	}
+	return plan
+}
+
+// linkTargets returns the services named by compose `links`, given as
+// "service" or "service:alias".
+func linkTargets(links []string) []string {
+	targets := make([]string, 0, len(links))
+	for _, link := range links {
+		target, _, _ := strings.Cut(link, ":")
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// serviceHosts returns the hostnames a service reaches the groups of the
+// compose file by, mapped to the owning group: the service and container
+// names, and the aliases of its links.
+func serviceHosts(dc *dockercompose.DockerCompose, ownerOf map[string]string, service dockercompose.Service) map[string]string {
+	hosts := make(map[string]string)
+	for serviceName, owner := range ownerOf {
+		hosts[serviceName] = owner
+		if containerName := dc.Services[serviceName].ContainerName; containerName != "" {
+			hosts[containerName] = owner
+		}
+	}
+	for _, link := range service.Links {
+		if target, alias, ok := strings.Cut(link, ":"); ok {
+			if owner, known := ownerOf[target]; known {
+				hosts[alias] = owner
+			}
+		}
+	}
+	return hosts
+}
+
+// containerPortOf returns the container port of a compose port mapping, or
+// an empty string for a port range or an invalid mapping.
+func containerPortOf(spec string) string {
+	spec, _, _ = strings.Cut(spec, "#")
+	spec, _, _ = strings.Cut(strings.TrimSpace(spec), "/")
+	port := spec[strings.LastIndex(spec, ":")+1:]
+	if portNumber(port) == 0 {
+		return ""
+	}
+	return port
+}
+
+// maxPort is the highest valid port number.
+const maxPort = 65535
+
+// portNumber returns the number of a port, or zero when it is not one.
+func portNumber(port string) int {
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 1 || number > maxPort {
+		return 0
+	}
+	return number
+}
+
+// rewriteUpstreamEnv returns the service with the references to the
+// upstreams of its group in environment values pointed at the local bind
+// ports.
+func (m *meshPlan) rewriteUpstreamEnv(dc *dockercompose.DockerCompose, groupName string, service dockercompose.Service) dockercompose.Service {
+	mesh := m.group(groupName)
+	env := parseKeyValues(service.Environment)
+	if mesh == nil || len(mesh.upstreams) == 0 || len(env) == 0 {
+		return service
+	}
+	bindPorts := make(map[string]int)
+	for _, upstream := range mesh.upstreams {
+		bindPorts[upstream.destination] = upstream.localBindPort
+	}
+
+	hosts := serviceHosts(dc, m.ownerOf, service)
+	rewritten := make(map[string]any, len(env))
+	for key, value := range env {
+		refs := findHostReferences(value, hosts)
+		rewritten[key] = replaceHostReferences(value, refs, func(ref hostReference) string {
+			bindPort, ok := bindPorts[hosts[ref.host]]
+			switch {
+			case !ok:
+				return value[ref.start:ref.end]
+			case ref.port == "":
+				return "localhost"
+			default:
+				return "localhost:" + strconv.Itoa(bindPort)
+			}
+		})
+	}
+	service.Environment = rewritten
+	return service
+}
+
+// addConnect writes the Connect sidecar of a group's service with its
+// upstreams.
+func addConnect(serviceBody *hclwrite.Body, mesh *groupMesh) {
+	sidecarBody := serviceBody.AppendNewBlock("connect", nil).Body().AppendNewBlock("sidecar_service", nil).Body()
+	if len(mesh.upstreams) == 0 {
+		return
+	}
+	proxyBody := sidecarBody.AppendNewBlock("proxy", nil).Body()
+	for _, upstream := range mesh.upstreams {
+		upstreamBody := proxyBody.AppendNewBlock("upstreams", nil).Body()
+		upstreamBody.SetAttributeValue("destination_name", cty.StringVal(upstream.destination))
+		upstreamBody.SetAttributeValue("local_bind_port", cty.NumberIntVal(int64(upstream.localBindPort)))
+	}
+}
@@ -0,0 +1,87 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const connectDockerComposeYAML = `
+services:
+  web:
+    image: web
+    ports:
+      - "8080:80"
+    environment:
+      DATABASE_URL: postgres://app@db:5432/app
+      CACHE_ADDR: cache:6379
+      GREETING: hello db
+    depends_on:
+      - db
+      - search
+  db:
+    image: postgres
+  cache:
+    image: redis
+    ports:
+      - "6379"
+  search:
+    image: elasticsearch
+  worker:
+    image: worker
+    links:
+      - cache:redis
+    environment:
+      QUEUE_URL: redis://redis:6379/0
+  relay:
+    image: relay
+    ports:
+      - "6379:6379"
+    environment:
+      UPSTREAM: http://cache:6379
+  exporter:
+    image: exporter
+    network_mode: host
+    environment:
+      TARGET: web:80
+`
+
+func TestConvertToNomadJobs_Connect(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(connectDockerComposeYAML, converter.Options{Connect: true})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	checks := map[string]string{
+		"bridge network":    `group "web"[\s\S]*?network\s*\{\s*mode\s*=\s*"bridge"`,
+		"consul service":    `service\s*\{\s*name\s*=\s*"web"\s*port\s*=\s*"80"\s*provider\s*=\s*"consul"`,
+		"web upstreams":     `upstreams\s*\{\s*destination_name\s*=\s*"cache"\s*local_bind_port\s*=\s*6379\s*\}\s*upstreams\s*\{\s*destination_name\s*=\s*"db"\s*local_bind_port\s*=\s*5432\s*\}`,
+		"db port":           `service\s*\{\s*name\s*=\s*"db"\s*port\s*=\s*"5432"\s*provider\s*=\s*"consul"\s*connect\s*\{\s*sidecar_service\s*\{\s*\}`,
+		"rewritten url":     `DATABASE_URL\s*=\s*"postgres://app@localhost:5432/app"`,
+		"rewritten address": `CACHE_ADDR\s*=\s*"localhost:6379"`,
+		"plain words":       `GREETING\s*=\s*"hello db"`,
+		"link alias":        `QUEUE_URL\s*=\s*"redis://localhost:6379/0"`,
+		"portless service":  `service\s*\{\s*name\s*=\s*"worker"\s*provider\s*=\s*"consul"`,
+		"unknown port":      `# The port of upstream 'search' is unknown`,
+		"taken bind port":   `# Upstream 'cache' is bound to local port 6380, as port 6379 is taken`,
+		"relay upstream":    `destination_name\s*=\s*"cache"\s*local_bind_port\s*=\s*6380`,
+		"relay env":         `UPSTREAM\s*=\s*"http://localhost:6380"`,
+		"host network":      `# Consul Connect requires bridge networking; group 'exporter' uses network mode host`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Contains(hclOutput, `provider = "nomad"`) {
+		t.Errorf("Connect services must be registered in Consul:\n%s", hclOutput)
+	}
+	if regexp.MustCompile(`group "web"[\s\S]*?ports\s*=\s*\["http"\][\s\S]*?group "worker"`).MatchString(hclOutput) {
+		t.Errorf("Tasks in the bridge network should leave the ports to the group:\n%s", hclOutput)
+	}
+}
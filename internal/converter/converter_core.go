@@ -3,7 +3,9 @@ package converter
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/zclconf/go-cty/cty"
@@ -13,24 +15,253 @@ import (
 	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
 )
 
+// defaultJobName is used when neither the options nor the compose project provide a name.
+const defaultJobName = "my-docker-compose-job"
+
+// Options controls how a Docker Compose file is converted into Nomad jobs.
+type Options struct {
+	// JobName is the name of the generated job. When empty, the compose project
+	// name is used, falling back to "my-docker-compose-job".
+	JobName string
+	// Split selects how services are distributed across jobs.
+	Split SplitMode
+	// Format selects the representation of the generated jobs.
+	Format OutputFormat
+	// Lint checks every generated job with LintNomadHCL and fails the
+	// conversion when a problem is found.
+	Lint bool
+	// Variables selects the job values lifted into HCL2 variables.
+	Variables VariableOptions
+	// Driver selects the task driver running the services, docker by default.
+	Driver TaskDriver
+	// MaxKillTimeout is the max_kill_timeout of the Nomad clients. Conversion
+	// fails when a stop_grace_period exceeds it. When zero, Nomad's default
+	// applies and longer grace periods are only noted.
+	MaxKillTimeout time.Duration
+	// DeployLabels selects where compose deploy labels are written.
+	DeployLabels DeployLabelTarget
+	// Provenance records the converter version, the compose file name and a
+	// hash of the compose input in the job meta.
+	Provenance bool
+	// SourceName is the compose file name recorded by Provenance.
+	SourceName string
+	// CNINetworks maps compose network names to the CNI networks joined by
+	// the groups attached to them. Unmapped networks become bridge networks.
+	CNINetworks map[string]string
+	// Connect adds the services to the Consul Connect service mesh. Their
+	// dependencies become sidecar upstreams, and references to them in
+	// environment values are pointed at the upstreams' local ports.
+	Connect bool
+	// Discovery selects how references to other services in env values and
+	// command args are resolved. References are left unchanged by default.
+	Discovery DiscoveryMode
+	// PlacementAttributes maps the Swarm node attributes of deploy.placement,
+	// such as "node.role" or "node.labels.zone", to Nomad node attributes such
+	// as "${node.class}". Entries override the default mapping, which covers
+	// node.id, node.hostname and node.platform and maps node and engine labels
+	// to node meta.
+	PlacementAttributes map[string]string
+	// Restart sets the restart policies the compose `restart` key maps to.
+	Restart RestartOptions
+}
+
+// OutputFormat selects the representation of the generated jobs.
+type OutputFormat string
+
+const (
+	// FormatHCL renders jobs as Nomad HCL job specifications.
+	FormatHCL OutputFormat = ""
+	// FormatJSON renders jobs as Nomad HTTP API payloads (`{"Job": {...}}`)
+	// that can be POSTed to /v1/jobs. The payloads are not built from the
+	// conversion directly: the HCL jobs are rendered first and parsed back
+	// with Nomad's jobspec parser, so the comments explaining what was not
+	// converted are lost.
+	FormatJSON OutputFormat = "json"
+	// FormatPack renders the jobs as a Nomad Pack, with images, counts, ports,
+	// resources and env values lifted into pack variables.
+	FormatPack OutputFormat = "pack"
+)
+
 // ConvertToNomadHCL converts a Docker Compose YAML string to Nomad HCL string.
-// This is the core logic, shared between WASM and native builds.
+// This is the core logic, shared between WASM and native builds. Compose files
+// whose services need several jobs fail, as one job file cannot hold them;
+// ConvertToNomadJobs returns those jobs as separate files.
 func ConvertToNomadHCL(yamlInput string) (string, error) {
+	files, err := ConvertToNomadJobs(yamlInput, Options{})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no jobs generated")
+	}
+	if len(files) > 1 {
+		return "", fmt.Errorf("the compose file converts to %d jobs (%s), which do not fit in one job file; use ConvertToNomadJobs to get one file per job", len(files), strings.Join(sortedKeys(files), ", "))
+	}
+	return files[sortedKeys(files)[0]], nil
+}
+
+// ConvertToNomadJobs converts a Docker Compose YAML string to one or more Nomad
+// jobs, returned as a map of file name to content in the requested format.
+func ConvertToNomadJobs(yamlInput string, opts Options) (map[string]string, error) {
+	dc, err := parseDockerCompose(yamlInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkStopSignals(dc); err != nil {
+		return nil, err
+	}
+	if err := checkKillTimeouts(dc, opts.MaxKillTimeout); err != nil {
+		return nil, err
+	}
+	if err := checkUpdateConfigs(dc); err != nil {
+		return nil, err
+	}
+	if err := checkRestartPolicies(dc); err != nil {
+		return nil, err
+	}
+	if err := checkRestartOptions(opts.Restart); err != nil {
+		return nil, err
+	}
+	switch opts.DeployLabels {
+	case DeployLabelsMeta, DeployLabelsTags:
+	default:
+		return nil, fmt.Errorf("unknown deploy label target %q", opts.DeployLabels)
+	}
+	switch opts.Discovery {
+	case DiscoveryNone, DiscoveryNomad, DiscoveryConsul, DiscoveryDNS:
+	default:
+		return nil, fmt.Errorf("unknown discovery mode %q", opts.Discovery)
+	}
+
+	periodic, schedulers, schedulerNotes, err := planPeriodic(dc)
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceName := range schedulers {
+		delete(dc.Services, serviceName)
+	}
+	jobs, err := planJobs(dc, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		for _, group := range job.groups {
+			for _, serviceName := range group.members() {
+				group.notes = append(group.notes, schedulerNotes[serviceName]...)
+			}
+		}
+	}
+	jobs, err = addPeriodicJobs(dc, opts, jobs, periodic)
+	if err != nil {
+		return nil, err
+	}
+
+	jobOf := make(map[string]string)
+	groupOf := make(map[string]string)
+	for _, job := range jobs {
+		for _, group := range job.groups {
+			for _, serviceName := range append(group.members(), group.prestart...) {
+				jobOf[serviceName] = job.name
+				groupOf[serviceName] = group.name
+			}
+		}
+	}
+
+	driver, err := driverFor(opts.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var mesh *meshPlan
+	if opts.Connect {
+		mesh = planMesh(dc, jobs, groupOf)
+	}
+
+	files := make(map[string]string, len(jobs))
+	var packJobs []*packJob
+	// The jobs of a pack share its variables, so their names are made unique
+	// across jobs.
+	packVariables := packParams()
+	for _, job := range jobs {
+		params, err := opts.Variables.params()
+		if err != nil {
+			return nil, err
+		}
+		renderer := &jobRenderer{dc: dc, opts: opts, job: job, jobOf: jobOf, groupOf: groupOf, params: params, driver: driver, mesh: mesh, sourceHash: inputHash(yamlInput)}
+		hclOutput, err := renderer.render()
+		if err != nil {
+			return nil, err
+		}
+		varsOutput := params.varsFile()
+		if opts.Lint {
+			if diags := LintNomadHCLWithVars(job.fileName(), hclOutput, varsOutput); diags.HasErrors() {
+				return nil, fmt.Errorf("generated job %s fails lint: %w", job.fileName(), diags)
+			}
+		}
+		switch opts.Format {
+		case FormatHCL:
+			files[job.fileName()] = hclOutput
+			if varsOutput != "" {
+				files[job.name+".vars.hcl"] = varsOutput
+			}
+		case FormatJSON:
+			jsonOutput, err := renderJobJSON(job.fileName(), hclOutput, varsOutput)
+			if err != nil {
+				return nil, err
+			}
+			files[job.name+".json"] = jsonOutput
+		case FormatPack:
+			renderer.params = packVariables
+			template, err := renderer.render()
+			if err != nil {
+				return nil, err
+			}
+			packJobs = append(packJobs, &packJob{name: job.name, template: template})
+		default:
+			return nil, fmt.Errorf("unknown output format %q", opts.Format)
+		}
+	}
+	if opts.Format == FormatPack {
+		return renderPack(defaultJobNameFor(dc, opts), packJobs, packVariables), nil
+	}
+	return files, nil
+}
+
+// parseDockerCompose unmarshals and sanity checks a Docker Compose YAML string.
+func parseDockerCompose(yamlInput string) (*dockercompose.DockerCompose, error) {
 	var dc dockercompose.DockerCompose
 	err := yaml.Unmarshal([]byte(yamlInput), &dc)
 	if err != nil {
-		return "", fmt.Errorf("error unmarshalling YAML: %w", err)
+		return nil, fmt.Errorf("error unmarshalling YAML: %w", err)
 	}
 
 	if len(dc.Services) == 0 {
-		return "", fmt.Errorf("no services found in Docker Compose file")
+		return nil, fmt.Errorf("no services found in Docker Compose file")
 	}
-
+	return &dc, nil
+}
+
+// jobRenderer holds the state shared while rendering the groups and tasks of a job.
+type jobRenderer struct {
+	dc      *dockercompose.DockerCompose
+	opts    Options
+	job     *jobPlan
+	jobOf   map[string]string // Job name of every service, for cross-job references
+	groupOf map[string]string // Group name of every service
+	params  *jobParams        // Values lifted into variables, nil to write every value literally
+	driver  taskDriver
+	mesh    *meshPlan // Consul Connect configuration, nil when disabled
+	// sourceHash is the hash of the compose input recorded in provenance meta.
+	sourceHash string
+}
+
+// render writes the Nomad job containing the services of the planned job.
+func (r *jobRenderer) render() (string, error) {
 	hclFile := hclwrite.NewEmptyFile()
 	rootBody := hclFile.Body()
 
-	jobName := "my-docker-compose-job" // Consider making this configurable or derived
-	jobBlock := rootBody.AppendNewBlock("job", []string{jobName})
+	jobBlock := rootBody.AppendNewBlock("job", []string{r.job.name})
 	jobBody := jobBlock.Body()
 
 	// Default datacenters, can be customized
@@ -39,382 +270,663 @@ func ConvertToNomadHCL(yamlInput string) (string, error) {
 	for i, s := range dcStrings {
 		dcVals[i] = cty.StringVal(s)
 	}
-	jobBody.SetAttributeValue("datacenters", cty.ListVal(dcVals))
-	jobBody.SetAttributeValue("type", cty.StringVal("service")) // Default job type
+	r.params.set(jobBody, "datacenters", paramDatacenters, "Datacenters the jobs are eligible to run in.", cty.ListVal(dcVals), "datacenters")
+	jobBody.SetAttributeValue("type", cty.StringVal(r.job.jobType))
+	r.addJobMeta(jobBody)
+	if r.job.periodic != nil {
+		addPeriodic(jobBody, r.job.periodic)
+	}
 	jobBody.AppendNewline()
 
-	for serviceName, service := range dc.Services {
-		groupBlock := jobBody.AppendNewBlock("group", []string{serviceName})
-		groupBody := groupBlock.Body()
+	for _, group := range r.job.groups {
+		r.addGroup(jobBody, group)
+		jobBody.AppendNewline()
+	}
 
+	jobBytes := hclFile.Bytes()
+	if r.params != nil && r.params.declare {
+		jobBytes = append(r.params.variableDeclarations(), jobBytes...)
+	}
+
+	formattedBytes := hclwrite.Format(jobBytes)
+	var buf bytes.Buffer
+	_, err := buf.Write(formattedBytes)
+	if err != nil {
+		return "", fmt.Errorf("error writing HCL: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// taskLifecycle describes the lifecycle block of a task that does not run as a main task.
+type taskLifecycle struct {
+	hook    string
+	sidecar bool
+}
+
+// addGroup appends a group running the planned services to the job body. The
+// owning service becomes the main task; services sharing its network namespace
+// are added as additional tasks on a shared bridge network.
+func (r *jobRenderer) addGroup(jobBody *hclwrite.Body, group *groupPlan) {
+	service := r.dc.Services[group.name]
+	groupBlock := jobBody.AppendNewBlock("group", []string{group.name})
+	groupBody := groupBlock.Body()
+
+	// System jobs run one instance per eligible node instead of a count.
+	var countNote string
+	if !isSystemJobType(r.job.jobType) {
+		count := int64(1) // Default to 1 replica
 		if service.Deploy != nil && service.Deploy.Replicas != nil {
-			groupBody.SetAttributeValue("count", cty.NumberIntVal(int64(*service.Deploy.Replicas)))
-		} else {
-			groupBody.SetAttributeValue("count", cty.NumberIntVal(int64(1))) // Default to 1 replica
+			count = int64(*service.Deploy.Replicas)
 		}
+		r.params.set(groupBody, "count", paramCount, fmt.Sprintf("Number of instances of the %s group.", group.name), cty.NumberIntVal(count), group.name, "count")
+	} else if service.Deploy != nil && service.Deploy.Replicas != nil {
+		countNote = fmt.Sprintf("Service '%s' runs on every eligible node in a %s job; deploy.replicas is ignored.", group.name, r.job.jobType)
+	}
+	deployLabels := r.groupDeployLabels(group)
+	if len(deployLabels) > 0 && r.opts.DeployLabels == DeployLabelsMeta {
+		groupBody.SetAttributeValue("meta", stringMapVal(deployLabels))
+	}
+	if len(groupBody.Attributes()) > 0 {
 		groupBody.AppendNewline()
+	}
 
-		taskBlock := groupBody.AppendNewBlock("task", []string{serviceName})
-		taskBody := taskBlock.Body()
-
-		taskBody.SetAttributeValue("driver", cty.StringVal("docker"))
-		taskBody.AppendNewline()
+	if countNote != "" {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(countNote))
+	}
+	for _, note := range group.notes {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	r.addPlacement(groupBody, group)
+	r.addUpdate(groupBody, group)
+	r.addReschedule(groupBody, group)
+	r.addEphemeralDisk(groupBody, group)
+
+	var ports []string
+	for _, memberName := range group.members() {
+		member := r.dc.Services[memberName]
+		ports = append(ports, member.Ports...)
+
+		// Services placed in another job are not co-located with this group, so
+		// they can only be reached through service discovery.
+		for _, dependency := range dependsOnNames(member.DependsOn) {
+			if depJob, ok := r.jobOf[dependency]; ok && depJob != r.job.name {
+				groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Depends on '%s' from job '%s'. Reach it through service discovery, e.g. {{ range nomadService \"%s\" }}.", dependency, depJob, dependency)))
+			}
+		}
+	}
+	for _, sidecarName := range group.sidecars {
+		if deploy := r.dc.Services[sidecarName].Deploy; deploy != nil && deploy.Replicas != nil {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Service '%s' shares the network namespace of '%s' and runs with its count; deploy.replicas is ignored.", sidecarName, group.name)))
+		}
+	}
 
-		configBlock := taskBody.AppendNewBlock("config", nil)
-		configBody := configBlock.Body()
-		configBody.SetAttributeValue("image", cty.StringVal(service.Image))
+	// Traefik reaches the service through its registration, so the port it
+	// forwards to must be published even when compose only exposes it on the
+	// container network.
+	traefik := traefikRoutingFor(r.groupTraefikLabels(group))
+	for _, note := range traefik.notes {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	if traefik.containerPort != "" && !publishesContainerPort(ports, traefik.containerPort) {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Traefik forwards to container port %s, which is not published; mapping it to a dynamic port.", traefik.containerPort)))
+		ports = append(ports, traefik.containerPort)
+	}
 
-		var generatedPortLabels []string
+	networking := r.groupNetworking(group)
+	for _, note := range networking.notes {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
 
-		if len(service.Ports) > 0 {
-			groupBody.AppendNewline()
-			networkBlock := groupBody.AppendNewBlock("network", nil)
-			networkBody := networkBlock.Body()
-
-			parsedPortInfos := []portutils.ProcessedPortInfo{}
-			for _, portMappingWithComment := range service.Ports {
-				portSpecPart := portMappingWithComment
-				comment := ""
-
-				if strings.Contains(portMappingWithComment, "#") {
-					parts := strings.SplitN(portMappingWithComment, "#", 2)
-					portSpecPart = strings.TrimSpace(parts[0])
-					if len(parts) > 1 {
-						comment = strings.TrimSpace(parts[1])
-					}
-				}
+	// Tasks sharing the group's network namespace are reachable through the
+	// group network, so only a lone task maps the ports itself. Tasks in the
+	// host network, or none, use it directly and map no ports.
+	networkMode := networking.mode
+	sharedNetwork := len(group.sidecars) > 0
+	hostMode := driverNetworkMode(service)
+	switch {
+	case hostMode != "":
+		if networking.mode != "" {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Service '%s' uses network_mode %s; its networks are ignored.", group.name, hostMode)))
+		}
+		if hostMode == networkModeNone && len(ports) > 0 {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Service '%s' has no network; its ports %s are dropped.", group.name, strings.Join(ports, ", "))))
+			ports = nil
+		}
+		networkMode = hostMode
+		sharedNetwork = false
+	case sharedNetwork && networkMode == "":
+		networkMode = "bridge"
+	}
 
-				containerPortWithoutProtocol := portSpecPart
-				if strings.Contains(portSpecPart, "/") {
-					containerPortWithoutProtocol = strings.Split(portSpecPart, "/")[0]
-				}
+	// Connect sidecars proxy through the group's bridge network namespace.
+	mesh := r.mesh.group(group.name)
+	if mesh != nil && networkMode != "" && networkMode != "bridge" {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Consul Connect requires bridge networking; group '%s' uses network mode %s and is left out of the mesh.", group.name, networkMode)))
+		mesh = nil
+	}
+	if mesh != nil {
+		networkMode = "bridge"
+		for _, note := range mesh.notes {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+		}
+	}
+	if hostMode == "" && networkMode != "" {
+		sharedNetwork = true
+	}
 
-				hostPortStr := ""
-				containerPortStr := ""
+	networkBlock, generatedPortLabels, containerPortLabels := r.buildNetwork(group.name, ports, networkMode)
+	taskPortLabels := generatedPortLabels
+	if sharedNetwork {
+		r.addNetworkIdentity(groupBody, networkBlock.Body(), group)
+	}
+	if sharedNetwork || hostMode != "" {
+		taskPortLabels = nil
+	}
 
-				if strings.Contains(containerPortWithoutProtocol, ":") {
-					parts := strings.SplitN(containerPortWithoutProtocol, ":", 2)
-					hostPortStr = parts[0]
-					containerPortStr = parts[1]
-				} else {
-					containerPortStr = containerPortWithoutProtocol
-				}
+	taskService := service
+	if mesh != nil {
+		taskService = r.mesh.rewriteUpstreamEnv(r.dc, group.name, service)
+	}
+	var namedVolumes []string
+	for _, prestartName := range group.prestart {
+		namedVolumes = append(namedVolumes, r.addTask(groupBody, prestartName, r.dc.Services[prestartName], nil, &taskLifecycle{hook: "prestart"}, sharedNetwork)...)
+		groupBody.AppendNewline()
+	}
+	namedVolumes = append(namedVolumes, r.addTask(groupBody, group.name, taskService, taskPortLabels, nil, sharedNetwork)...)
+	for _, sidecarName := range group.sidecars {
+		sidecar := r.dc.Services[sidecarName]
+		if hostMode != "" {
+			sidecar.NetworkMode = service.NetworkMode
+		}
+		if mesh != nil {
+			sidecar = r.mesh.rewriteUpstreamEnv(r.dc, group.name, sidecar)
+		}
+		groupBody.AppendNewline()
+		namedVolumes = append(namedVolumes, r.addTask(groupBody, sidecarName, sidecar, nil, sidecarLifecycle(r.dc, sidecarName), sharedNetwork)...)
+	}
 
-				if containerPortStr == "" {
-					taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Skipping invalid port spec: %s", portMappingWithComment)))
-					continue
-				}
+	// Named compose volumes are mounted from host volumes of the same name,
+	// which every volume_mount must reference through a group volume.
+	declaredVolumes := make(map[string]bool)
+	for _, volumeName := range namedVolumes {
+		if declaredVolumes[volumeName] {
+			continue
+		}
+		declaredVolumes[volumeName] = true
+		groupBody.AppendNewline()
+		volumeBody := groupBody.AppendNewBlock("volume", []string{volumeName}).Body()
+		volumeBody.SetAttributeValue("type", cty.StringVal("host"))
+		volumeBody.SetAttributeValue("source", cty.StringVal(volumeName))
+	}
 
-				strippedContainerPort := containerPortStr
-				if strings.Contains(containerPortStr, "/") {
-					strippedContainerPort = strings.Split(containerPortStr, "/")[0]
-				}
+	if networkBlock != nil {
+		groupBody.AppendNewline()
+		groupBody.AppendBlock(networkBlock)
+	}
 
-				parsedPortInfos = append(parsedPortInfos, portutils.ProcessedPortInfo{
-					OriginalHostPort:      hostPortStr,
-					OriginalContainerPort: containerPortStr,
-					Comment:               comment,
-					ProtocolStrippedPort:  strippedContainerPort,
-				})
+	if len(generatedPortLabels) > 0 || mesh != nil {
+		// Register the service so that other groups and jobs can discover it.
+		// Connect services live in Consul and are registered on the container
+		// port their sidecar proxies to.
+		groupBody.AppendNewline()
+		serviceBlock := groupBody.AppendNewBlock("service", nil)
+		serviceBody := serviceBlock.Body()
+		var servicePort string
+		if len(generatedPortLabels) > 0 {
+			servicePort = generatedPortLabels[0]
+		}
+		if label, ok := containerPortLabels[traefik.containerPort]; ok {
+			servicePort = label
+		}
+		provider := r.serviceProvider()
+		serviceBody.SetAttributeValue("name", cty.StringVal(group.name))
+		if mesh != nil {
+			provider = "consul"
+			if mesh.port != "" {
+				serviceBody.SetAttributeValue("port", cty.StringVal(mesh.port))
 			}
+		} else {
+			serviceBody.SetAttributeValue("port", cty.StringVal(servicePort))
+		}
+		serviceBody.SetAttributeValue("provider", cty.StringVal(provider))
+		var tags []string
+		if r.opts.DeployLabels == DeployLabelsTags {
+			tags = deployLabelTags(deployLabels)
+		}
+		tags = append(tags, traefik.tags...)
+		if len(tags) > 0 {
+			serviceBody.SetAttributeValue("tags", stringListVal(tags))
+		}
+		if mesh != nil {
+			serviceBody.AppendNewline()
+			addConnect(serviceBody, mesh)
+		}
 
-			consolidatedPortMap := make(map[string]portutils.ProcessedPortInfo)
-			for _, pInfo := range parsedPortInfos {
-				if _, exists := consolidatedPortMap[pInfo.ProtocolStrippedPort]; !exists {
-					consolidatedPortMap[pInfo.ProtocolStrippedPort] = pInfo
-				}
-			}
+		// Network aliases are how other services reached this one under
+		// compose, so each is registered as a service name as well.
+		if servicePort == "" && len(networking.aliases) > 0 {
+			groupBody.AppendNewline()
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Group '%s' publishes no port to register a service on, so its network aliases are not registered.", group.name)))
+			return
+		}
+		for _, alias := range networking.aliases {
+			groupBody.AppendNewline()
+			aliasBody := groupBody.AppendNewBlock("service", nil).Body()
+			aliasBody.SetAttributeValue("name", cty.StringVal(alias))
+			aliasBody.SetAttributeValue("port", cty.StringVal(servicePort))
+			aliasBody.SetAttributeValue("provider", cty.StringVal(provider))
+		}
+		return
+	}
+	if len(networking.aliases) > 0 {
+		groupBody.AppendNewline()
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Group '%s' publishes no port to register a service on, so its network aliases are not registered.", group.name)))
+	}
+	if len(deployLabels) > 0 && r.opts.DeployLabels == DeployLabelsTags {
+		groupBody.AppendNewline()
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Group '%s' registers no service, so its deploy labels are written to meta instead of tags.", group.name)))
+		groupBody.SetAttributeValue("meta", stringMapVal(deployLabels))
+	}
+	if len(traefik.tags) > 0 {
+		groupBody.AppendNewline()
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Group '%s' publishes no port to register a service on, so its Traefik labels are dropped.", group.name)))
+	}
+}
+
+// addTask appends a task running the given service to the group body
+// and returns the named volumes it mounts. The lifecycle is nil for main tasks.
+// Tasks in a shared network leave the hostname and DNS to the group network.
+func (r *jobRenderer) addTask(groupBody *hclwrite.Body, serviceName string, service dockercompose.Service, generatedPortLabels []string, lifecycle *taskLifecycle, sharedNetwork bool) []string {
+	taskBlock := groupBody.AppendNewBlock("task", []string{serviceName})
+	taskBody := taskBlock.Body()
+	service, discovery := r.serviceDiscovery(serviceName, service)
+
+	taskBody.SetAttributeValue("driver", cty.StringVal(r.driver.name()))
+	if service.User != "" {
+		taskBody.SetAttributeValue("user", cty.StringVal(service.User))
+	}
+	r.addShutdown(taskBody, service)
+	taskBody.AppendNewline()
+
+	if lifecycle != nil {
+		lifecycleBody := taskBody.AppendNewBlock("lifecycle", nil).Body()
+		lifecycleBody.SetAttributeValue("hook", cty.StringVal(lifecycle.hook))
+		if lifecycle.sidecar {
+			lifecycleBody.SetAttributeValue("sidecar", cty.True)
+		}
+		taskBody.AppendNewline()
+	}
 
-			isFirstPortInBlock := true
-			for _, finalPInfo := range consolidatedPortMap {
-				var portLabel string
-				sanitizedComment := portutils.SanitizeCommentToLabel(finalPInfo.Comment)
-				if sanitizedComment != "" {
-					portLabel = sanitizedComment
-				} else {
-					wellKnownLabel := portutils.GetWellKnownPortLabel(finalPInfo.ProtocolStrippedPort)
-					if wellKnownLabel != "" {
-						portLabel = wellKnownLabel
-					} else {
-						portLabel = "port_" + finalPInfo.ProtocolStrippedPort
-					}
-				}
+	configBlock := taskBody.AppendNewBlock("config", nil)
+	configBody := configBlock.Body()
+	image := r.driver.image(service.Image)
+	if prefix, tag, ok := splitImageTag(image); ok && r.params.includes(paramImageTag, "image") {
+		r.params.setInterpolated(configBody, "image", paramImageTag, fmt.Sprintf("Image tag of the %s task.", serviceName), prefix, tag, serviceName, "image", "tag")
+	} else {
+		r.params.set(configBody, "image", paramImage, fmt.Sprintf("Docker image of the %s task.", serviceName), cty.StringVal(image), serviceName, "image")
+	}
+	r.driver.setAuth(configBody, image)
 
-				if !isFirstPortInBlock {
-					networkBody.AppendNewline()
-				}
-				isFirstPortInBlock = false
-
-				nomadPortBlock := networkBody.AppendNewBlock("port", []string{portLabel})
-				nomadPortBody := nomadPortBlock.Body()
-
-				if finalPInfo.OriginalHostPort != "" {
-					hostPortVal, err := portutils.ParseInt64ForPort(finalPInfo.OriginalHostPort)
-					if err != nil {
-						errMsg := fmt.Sprintf("Error parsing host port '%s' for label '%s': %s", finalPInfo.OriginalHostPort, portLabel, err.Error())
-						taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(errMsg))
-						continue
-					}
-
-					containerPortVal, err := portutils.ParseInt64ForPort(finalPInfo.OriginalContainerPort)
-					if err != nil {
-						errMsg := fmt.Sprintf("Error parsing container port '%s' for label '%s': %s", finalPInfo.OriginalContainerPort, portLabel, err.Error())
-						taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(errMsg))
-						continue
-					}
-
-					nomadPortBody.SetAttributeValue("static", cty.NumberIntVal(hostPortVal))
-					if hostPortVal != containerPortVal {
-						nomadPortBody.SetAttributeValue("to", cty.NumberIntVal(containerPortVal))
-					}
-				} else {
-					containerPortVal, err := portutils.ParseInt64ForPort(finalPInfo.ProtocolStrippedPort)
-					if err != nil {
-						errMsg := fmt.Sprintf("Error parsing container port '%s' for label '%s': %s", finalPInfo.ProtocolStrippedPort, portLabel, err.Error())
-						taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(errMsg))
-						continue
-					}
-					nomadPortBody.SetAttributeValue("to", cty.NumberIntVal(containerPortVal))
+	if len(generatedPortLabels) > 0 {
+		r.driver.setPorts(configBody, generatedPortLabels)
+	}
+
+	var bindVolumes []string
+	var namedVolumes []string
+	hasAnyVolumesInSection := false
+
+	if len(service.Volumes) > 0 {
+		for _, volSpec := range service.Volumes {
+			if !hasAnyVolumesInSection {
+				if len(configBlock.Body().Attributes()) > 1 || len(generatedPortLabels) > 0 {
+					taskBody.AppendNewline()
 				}
-				generatedPortLabels = append(generatedPortLabels, portLabel)
+				hasAnyVolumesInSection = true
 			}
-		}
-
-		if len(generatedPortLabels) > 0 {
-			portLabelCtyValues := make([]cty.Value, len(generatedPortLabels))
-			for i, label := range generatedPortLabels {
-				portLabelCtyValues[i] = cty.StringVal(label)
+			parts := strings.SplitN(volSpec, ":", 3)
+			if len(parts) < 1 {
+				taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Skipping invalid volume spec: %s.", volSpec)))
+				continue
 			}
-			configBody.SetAttributeValue("ports", cty.ListVal(portLabelCtyValues))
-		}
 
-		var dockerDriverVolumes []cty.Value
-		hasAnyVolumesInSection := false
+			source := parts[0]
+			var destination string
+			options := ""
 
-		if len(service.Volumes) > 0 {
-			for _, volSpec := range service.Volumes {
-				if !hasAnyVolumesInSection {
-					if len(configBlock.Body().Attributes()) > 1 || len(generatedPortLabels) > 0 {
-						taskBody.AppendNewline()
-					}
-					hasAnyVolumesInSection = true
-				}
-				parts := strings.SplitN(volSpec, ":", 3)
-				if len(parts) < 1 {
-					taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Skipping invalid volume spec: %s.", volSpec)))
+			if len(parts) == 1 {
+				if strings.Contains(source, "/") && !strings.HasPrefix(source, "./") {
+					taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Anonymous volume '%s' needs mapping to a host path or named Nomad volume.", source)))
 					continue
 				}
+				destination = source
+			} else {
+				destination = parts[1]
+				if len(parts) == 3 {
+					options = parts[2]
+				}
+			}
+			isReadOnly := strings.Contains(options, "ro")
 
-				source := parts[0]
-				var destination string
-				options := ""
-
-				if len(parts) == 1 {
-					if strings.Contains(source, "/") && !strings.HasPrefix(source, "./") {
-						taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Anonymous volume '%s' needs mapping to a host path or named Nomad volume.", source)))
-						continue
-					}
-					destination = source
-				} else {
-					destination = parts[1]
-					if len(parts) == 3 {
-						options = parts[2]
-					}
+			if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "/") {
+				if strings.HasPrefix(source, "./") {
+					taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Mapping relative host path '%s'. In Nomad, this is relative to task alloc dir.", source)))
 				}
-				isReadOnly := strings.Contains(options, "ro")
-
-				if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "/") {
-					if strings.HasPrefix(source, "./") {
-						taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Mapping relative host path '%s'. In Nomad, this is relative to task alloc dir.", source)))
-					}
-					volumeString := fmt.Sprintf("%s:%s", source, destination)
-					if isReadOnly {
-						volumeString += ":ro"
-					}
-					dockerDriverVolumes = append(dockerDriverVolumes, cty.StringVal(volumeString))
-				} else {
-					commentText := fmt.Sprintf("Ensure Nomad volume '%s' is defined in the job or cluster.", source)
-					taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(commentText))
-
-					vmBlock := taskBody.AppendNewBlock("volume_mount", nil)
-					vmBody := vmBlock.Body()
-					vmBody.SetAttributeValue("volume", cty.StringVal(source))
-					vmBody.SetAttributeValue("destination", cty.StringVal(destination))
-					vmBody.SetAttributeValue("read_only", cty.BoolVal(isReadOnly))
-					taskBody.AppendNewline()
+				volumeString := fmt.Sprintf("%s:%s", source, destination)
+				if isReadOnly {
+					volumeString += ":ro"
 				}
+				bindVolumes = append(bindVolumes, volumeString)
+			} else {
+				commentText := fmt.Sprintf("Ensure Nomad volume '%s' is defined in the job or cluster.", source)
+				taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(commentText))
+
+				vmBlock := taskBody.AppendNewBlock("volume_mount", nil)
+				vmBody := vmBlock.Body()
+				vmBody.SetAttributeValue("volume", cty.StringVal(source))
+				vmBody.SetAttributeValue("destination", cty.StringVal(destination))
+				vmBody.SetAttributeValue("read_only", cty.BoolVal(isReadOnly))
+				taskBody.AppendNewline()
+				namedVolumes = append(namedVolumes, source)
 			}
 		}
-		if len(dockerDriverVolumes) > 0 {
-			configBody.SetAttributeValue("volumes", cty.ListVal(dockerDriverVolumes))
+	}
+	if len(bindVolumes) > 0 {
+		r.driver.setVolumes(configBody, bindVolumes)
+	}
+
+	if len(configBlock.Body().Attributes()) > 1 || hasAnyVolumesInSection {
+		taskBody.AppendNewline()
+	}
+
+	envVarsToAdd := parseKeyValues(service.Environment)
+	if len(envVarsToAdd) > 0 {
+		envBlock := taskBody.AppendNewBlock("env", nil)
+		envBody := envBlock.Body()
+		// Sort keys for consistent output
+		for _, key := range sortedKeys(envVarsToAdd) {
+			r.params.set(envBody, key, paramEnv, fmt.Sprintf("Value of %s in the %s task.", key, serviceName), cty.StringVal(envVarsToAdd[key]), serviceName, "env", key)
 		}
+		taskBody.AppendNewline()
+	}
 
-		if len(configBlock.Body().Attributes()) > 1 || hasAnyVolumesInSection {
+	// References to other services are resolved when the task starts.
+	for _, note := range discovery.notes {
+		taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	if len(discovery.lines) > 0 {
+		addDiscoveryTemplate(taskBody, discovery)
+		taskBody.AppendNewline()
+	}
+
+	r.addResources(taskBody, serviceName, service)
+	r.addLogging(taskBody, configBody, service)
+
+	command, args := commandAndArgs(service.Entrypoint, service.Command)
+	r.driver.setCommand(configBody, command, args)
+	r.addSecurity(configBody, service)
+	r.addTuning(configBody, service)
+	r.addIdentity(configBody, service, sharedNetwork)
+	if labels := containerLabels(service); len(labels) > 0 {
+		r.driver.setLabels(configBody, labels)
+	}
+
+	if service.Restart != "" || service.Deploy != nil && service.Deploy.RestartPolicy != nil {
+		if len(envVarsToAdd) > 0 || hasAnyVolumesInSection || len(configBlock.Body().Attributes()) > 1 {
 			taskBody.AppendNewline()
 		}
+		r.addRestart(taskBody, service)
+		taskBody.AppendNewline()
+	}
+	return namedVolumes
+}
+
+// buildNetwork returns a network block for the named group in the given mode with a port for every
+// compose port mapping, along with the generated port labels and the label of
+// each container port. Problems with individual mappings are reported as
+// comments in the network block. No block is returned when there is neither a
+// mode nor a port to declare.
+func (r *jobRenderer) buildNetwork(groupName string, ports []string, mode string) (*hclwrite.Block, []string, map[string]string) {
+	if len(ports) == 0 && mode == "" {
+		return nil, nil, nil
+	}
 
-		envVarsToAdd := make(map[string]string)
-		if service.Environment != nil {
-			switch envTyped := service.Environment.(type) {
-			case map[string]string: // Handles environments explicitly defined as map[string]string
-				for key, val := range envTyped {
-					envVarsToAdd[key] = val
-				}
-			case map[any]any: // Handles environments unmarshalled as map[interface{}]interface{} by go-yaml
-				for k, v := range envTyped {
-					keyStr, keyOk := k.(string)
-					if !keyOk {
-						// Silently ignore non-string keys
-						continue
-					}
-
-					var valStr string
-					if v == nil { // Handles VAR: (null value in YAML)
-						valStr = ""
-					} else {
-						vStr, valIsString := v.(string)
-						if !valIsString {
-							// Attempt to convert to string if it's not nil and not a string (e.g. int, bool)
-							// For Nomad env vars, values are ultimately strings.
-							valStr = fmt.Sprintf("%v", v)
-						} else {
-							valStr = vStr
-						}
-					}
-					envVarsToAdd[keyStr] = valStr
-				}
-			case map[string]any: // Handles environments unmarshalled as map[string]interface{}
-				for key, v := range envTyped {
-					var valStr string
-					if v == nil {
-						valStr = ""
-					} else {
-						vStr, valIsString := v.(string)
-						if !valIsString {
-							valStr = fmt.Sprintf("%v", v)
-						} else {
-							valStr = vStr
-						}
-					}
-					envVarsToAdd[key] = valStr
-				}
-			case []any: // Likely []interface{} from YAML unmarshal for list format
-				for _, item := range envTyped {
-					if s, ok := item.(string); ok {
-						parts := strings.SplitN(s, "=", 2)
-						if len(parts) == 2 {
-							envVarsToAdd[parts[0]] = parts[1]
-						} else if len(parts) == 1 { // Handle VAR (no =VALUE)
-							// Docker Compose would take this from the shell.
-							// Nomad env block sets vars directly. Set to empty string.
-							envVarsToAdd[parts[0]] = ""
-						}
-						// Silently ignore malformed entries like "=VAL" or "KEY=VAL=EXTRA" (SplitN handles this well for KEY=VAL)
-					}
-				}
-				// Note: `case []string:` is covered by `[]any` due to how `yaml.Unmarshal` works with `any`.
-			}
+	var generatedPortLabels []string
+	containerPortLabels := make(map[string]string)
+
+	networkBlock := hclwrite.NewBlock("network", nil)
+	networkBody := networkBlock.Body()
+	if mode != "" {
+		networkBody.SetAttributeValue("mode", cty.StringVal(mode))
+		if len(ports) > 0 {
+			networkBody.AppendNewline()
 		}
+	}
+
+	parsedPortInfos := []portutils.ProcessedPortInfo{}
+	for _, portMappingWithComment := range ports {
+		portSpecPart := portMappingWithComment
+		comment := ""
 
-		if len(envVarsToAdd) > 0 {
-			envBlock := taskBody.AppendNewBlock("env", nil)
-			envBody := envBlock.Body()
-			// Sort keys for consistent output (optional, but good for testability)
-			// For now, iterate directly as order isn't strictly critical for functionality
-			for key, val := range envVarsToAdd {
-				envBody.SetAttributeValue(key, cty.StringVal(val))
+		if strings.Contains(portMappingWithComment, "#") {
+			parts := strings.SplitN(portMappingWithComment, "#", 2)
+			portSpecPart = strings.TrimSpace(parts[0])
+			if len(parts) > 1 {
+				comment = strings.TrimSpace(parts[1])
 			}
-			taskBody.AppendNewline()
 		}
 
-		var cmdVal cty.Value
-		var argsVal cty.Value
-		var entrypointParts []string
-		if service.Entrypoint != nil {
-			switch e := service.Entrypoint.(type) {
-			case string:
-				entrypointParts = []string{e}
-			case []any:
-				for _, item := range e {
-					if s, ok := item.(string); ok {
-						entrypointParts = append(entrypointParts, s)
-					}
-				}
-			}
+		containerPortWithoutProtocol := portSpecPart
+		if strings.Contains(portSpecPart, "/") {
+			containerPortWithoutProtocol = strings.Split(portSpecPart, "/")[0]
 		}
-		var commandParts []string
-		if service.Command != nil {
-			switch c := service.Command.(type) {
-			case string:
-				commandParts = strings.Fields(c)
-			case []any:
-				for _, item := range c {
-					if s, ok := item.(string); ok {
-						commandParts = append(commandParts, s)
-					}
-				}
+
+		hostPortStr := ""
+		containerPortStr := ""
+
+		if strings.Contains(containerPortWithoutProtocol, ":") {
+			parts := strings.SplitN(containerPortWithoutProtocol, ":", 2)
+			hostPortStr = parts[0]
+			containerPortStr = parts[1]
+		} else {
+			containerPortStr = containerPortWithoutProtocol
+		}
+
+		if containerPortStr == "" {
+			networkBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Skipping invalid port spec: %s", portMappingWithComment)))
+			continue
+		}
+
+		strippedContainerPort := containerPortStr
+		if strings.Contains(containerPortStr, "/") {
+			strippedContainerPort = strings.Split(containerPortStr, "/")[0]
+		}
+
+		parsedPortInfos = append(parsedPortInfos, portutils.ProcessedPortInfo{
+			OriginalHostPort:      hostPortStr,
+			OriginalContainerPort: containerPortStr,
+			Comment:               comment,
+			ProtocolStrippedPort:  strippedContainerPort,
+		})
+	}
+
+	// Consolidate mappings of the same container port (e.g. 53/tcp and 53/udp),
+	// keeping the order in which they were declared.
+	var consolidatedPorts []portutils.ProcessedPortInfo
+	seenPorts := make(map[string]bool)
+	for _, pInfo := range parsedPortInfos {
+		if !seenPorts[pInfo.ProtocolStrippedPort] {
+			seenPorts[pInfo.ProtocolStrippedPort] = true
+			consolidatedPorts = append(consolidatedPorts, pInfo)
+		}
+	}
+
+	isFirstPortInBlock := true
+	for _, finalPInfo := range consolidatedPorts {
+		var portLabel string
+		sanitizedComment := portutils.SanitizeCommentToLabel(finalPInfo.Comment)
+		if sanitizedComment != "" {
+			portLabel = sanitizedComment
+		} else {
+			wellKnownLabel := portutils.GetWellKnownPortLabel(finalPInfo.ProtocolStrippedPort)
+			if wellKnownLabel != "" {
+				portLabel = wellKnownLabel
+			} else {
+				portLabel = "port_" + finalPInfo.ProtocolStrippedPort
 			}
 		}
-		if len(entrypointParts) > 0 {
-			cmdVal = cty.StringVal(entrypointParts[0])
-			var finalArgs []string
-			if len(entrypointParts) > 1 {
-				finalArgs = append(finalArgs, entrypointParts[1:]...)
+
+		if !isFirstPortInBlock {
+			networkBody.AppendNewline()
+		}
+		isFirstPortInBlock = false
+
+		nomadPortBlock := networkBody.AppendNewBlock("port", []string{portLabel})
+		nomadPortBody := nomadPortBlock.Body()
+
+		if finalPInfo.OriginalHostPort != "" {
+			hostPortVal, err := portutils.ParseInt64ForPort(finalPInfo.OriginalHostPort)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error parsing host port '%s' for label '%s': %s", finalPInfo.OriginalHostPort, portLabel, err.Error())
+				networkBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(errMsg))
+				continue
 			}
-			finalArgs = append(finalArgs, commandParts...)
-			if len(finalArgs) > 0 {
-				argCtyVals := make([]cty.Value, len(finalArgs))
-				for i, arg := range finalArgs {
-					argCtyVals[i] = cty.StringVal(arg)
-				}
-				argsVal = cty.ListVal(argCtyVals)
+
+			containerPortVal, err := portutils.ParseInt64ForPort(finalPInfo.OriginalContainerPort)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error parsing container port '%s' for label '%s': %s", finalPInfo.OriginalContainerPort, portLabel, err.Error())
+				networkBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(errMsg))
+				continue
 			}
-		} else if len(commandParts) > 0 {
-			cmdVal = cty.StringVal(commandParts[0])
-			if len(commandParts) > 1 {
-				argCtyVals := make([]cty.Value, len(commandParts)-1)
-				for i, arg := range commandParts[1:] {
-					argCtyVals[i] = cty.StringVal(arg)
+
+			// Host networking has no port translation: the service binds the
+			// container port on the host.
+			if mode == "host" {
+				if hostPortVal != containerPortVal {
+					networkBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Port mapping %s:%s has no effect with host networking; the service listens on host port %d.", finalPInfo.OriginalHostPort, finalPInfo.OriginalContainerPort, containerPortVal)))
 				}
-				argsVal = cty.ListVal(argCtyVals)
+				hostPortVal = containerPortVal
 			}
+			r.params.set(nomadPortBody, "static", paramPort, fmt.Sprintf("Host port of %s in the %s group.", portLabel, groupName), cty.NumberIntVal(hostPortVal), groupName, portLabel, "port")
+			if hostPortVal != containerPortVal {
+				nomadPortBody.SetAttributeValue("to", cty.NumberIntVal(containerPortVal))
+			}
+		} else {
+			containerPortVal, err := portutils.ParseInt64ForPort(finalPInfo.ProtocolStrippedPort)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error parsing container port '%s' for label '%s': %s", finalPInfo.ProtocolStrippedPort, portLabel, err.Error())
+				networkBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(errMsg))
+				continue
+			}
+			if mode == "host" {
+				r.params.set(nomadPortBody, "static", paramPort, fmt.Sprintf("Host port of %s in the %s group.", portLabel, groupName), cty.NumberIntVal(containerPortVal), groupName, portLabel, "port")
+			} else {
+				nomadPortBody.SetAttributeValue("to", cty.NumberIntVal(containerPortVal))
+			}
+		}
+		generatedPortLabels = append(generatedPortLabels, portLabel)
+		containerPortLabels[finalPInfo.ProtocolStrippedPort] = portLabel
+	}
+	return networkBlock, generatedPortLabels, containerPortLabels
+}
+
+// parseKeyValues normalizes compose key/value sections such as `environment`
+// and `labels`, which can be written either as a map or as a list of KEY=VALUE.
+func parseKeyValues(raw any) map[string]string {
+	values := make(map[string]string)
+	if raw == nil {
+		return values
+	}
+	switch typed := raw.(type) {
+	case map[string]string: // Handles sections explicitly defined as map[string]string
+		for key, val := range typed {
+			values[key] = val
 		}
-		if !cmdVal.IsNull() && cmdVal.IsKnown() {
-			configBody.SetAttributeValue("command", cmdVal)
+	case map[any]any: // Handles sections unmarshalled as map[interface{}]interface{} by go-yaml
+		for k, v := range typed {
+			keyStr, keyOk := k.(string)
+			if !keyOk {
+				// Silently ignore non-string keys
+				continue
+			}
+			values[keyStr] = scalarString(v)
 		}
-		if !argsVal.IsNull() && argsVal.IsKnown() {
-			configBody.SetAttributeValue("args", argsVal)
+	case map[string]any: // Handles sections unmarshalled as map[string]interface{}
+		for key, v := range typed {
+			values[key] = scalarString(v)
 		}
-
-		if service.Restart != "" {
-			if len(envVarsToAdd) > 0 || hasAnyVolumesInSection || len(configBlock.Body().Attributes()) > 1 {
-				taskBody.AppendNewline()
+	case []any: // Likely []interface{} from YAML unmarshal for list format
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				parts := strings.SplitN(s, "=", 2)
+				if len(parts) == 2 {
+					values[parts[0]] = parts[1]
+				} else if len(parts) == 1 { // Handle VAR (no =VALUE)
+					// Docker Compose would take this from the shell.
+					// Nomad env block sets vars directly. Set to empty string.
+					values[parts[0]] = ""
+				}
+				// Silently ignore malformed entries like "=VAL" or "KEY=VAL=EXTRA" (SplitN handles this well for KEY=VAL)
 			}
-			restartBlock := taskBody.AppendNewBlock("restart", nil)
-			restartBody := restartBlock.Body()
-			switch service.Restart {
-			case "always", "unless-stopped":
-				restartBody.SetAttributeValue("attempts", cty.NumberIntVal(0))
-				restartBody.SetAttributeValue("delay", cty.StringVal("15s"))
-				restartBody.SetAttributeValue("mode", cty.StringVal("delay"))
-			case "on-failure":
-				restartBody.SetAttributeValue("attempts", cty.NumberIntVal(3))
-				restartBody.SetAttributeValue("interval", cty.StringVal("1m"))
-				restartBody.SetAttributeValue("mode", cty.StringVal("fail"))
-			case "no":
-				restartBody.SetAttributeValue("attempts", cty.NumberIntVal(0))
-				restartBody.SetAttributeValue("mode", cty.StringVal("fail"))
+		}
+		// Note: `case []string:` is covered by `[]any` due to how `yaml.Unmarshal` works with `any`.
+	}
+	return values
+}
+
+// scalarString renders a YAML scalar as a string. Null values (VAR: in YAML)
+// become the empty string, and non-string scalars (e.g. int, bool) are
+// formatted since Nomad values are ultimately strings.
+func scalarString(v any) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// sortedKeys returns the keys of a string map in lexical order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringList normalizes compose values that can be either a single string or a
+// list of strings. A single string is split on whitespace when split is true.
+func stringList(raw any, split bool) []string {
+	var parts []string
+	switch typed := raw.(type) {
+	case string:
+		if split {
+			parts = strings.Fields(typed)
+		} else {
+			parts = []string{typed}
+		}
+	case []any:
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
 			}
-			taskBody.AppendNewline()
 		}
-		jobBody.AppendNewline()
 	}
-
-	formattedBytes := hclwrite.Format(hclFile.Bytes())
-	var buf bytes.Buffer
-	_, err = buf.Write(formattedBytes)
-	if err != nil {
-		return "", fmt.Errorf("error writing HCL: %w", err)
+	return parts
+}
+
+// commandAndArgs maps compose entrypoint and command onto the command and
+// args of the task driver. The command is empty when neither is set.
+func commandAndArgs(entrypoint any, command any) (string, []string) {
+	entrypointParts := stringList(entrypoint, false)
+	commandParts := stringList(command, true)
+	if len(entrypointParts) > 0 {
+		return entrypointParts[0], append(entrypointParts[1:], commandParts...)
 	}
-	return buf.String(), nil
-}
\ No newline at end of file
+	if len(commandParts) > 0 {
+		return commandParts[0], commandParts[1:]
+	}
+	return "", nil
+}
@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// Nomad job types, in the order they claim a job's name when a job is split
+// by type.
+const (
+	jobTypeService  = "service"
+	jobTypeSystem   = "system"
+	jobTypeBatch    = "batch"
+	jobTypeSysbatch = "sysbatch"
+)
+
+var jobTypeOrder = []string{jobTypeService, jobTypeSystem, jobTypeBatch, jobTypeSysbatch}
+
+// deployModeJobTypes maps Swarm deploy modes to the Nomad job types running
+// them.
+var deployModeJobTypes = map[string]string{
+	"":               jobTypeService,
+	"replicated":     jobTypeService,
+	"global":         jobTypeSystem,
+	"replicated-job": jobTypeBatch,
+	"global-job":     jobTypeSysbatch,
+}
+
+// deployMode returns the Swarm deploy mode of a service.
+func deployMode(service dockercompose.Service) string {
+	if service.Deploy == nil {
+		return ""
+	}
+	return service.Deploy.Mode
+}
+
+// groupJobType returns the Nomad job type of a group, given by the deploy mode
+// of the owning service. Owners running to completion run in batch jobs
+// instead of service jobs, or sysbatch jobs instead of system jobs. Services
+// folded into the group run with it, so their differing deploy modes are
+// noted.
+func groupJobType(dc *dockercompose.DockerCompose, group *groupPlan) (string, error) {
+	mode := deployMode(dc.Services[group.name])
+	jobType, ok := deployModeJobTypes[mode]
+	if !ok {
+		return "", fmt.Errorf("unknown deploy mode %q of service %q", mode, group.name)
+	}
+	if reason := oneShotReason(dc, group.name); reason != "" && !isBatchJobType(jobType) {
+		jobType = jobTypeBatch
+		if mode == "global" {
+			jobType = jobTypeSysbatch
+		}
+		group.notes = append(group.notes, fmt.Sprintf("Service '%s' runs to completion (%s), so it runs in a %s job.", group.name, reason, jobType))
+	}
+	for _, sidecarName := range group.sidecars {
+		sidecarMode := deployMode(dc.Services[sidecarName])
+		if deployModeJobTypes[sidecarMode] != jobType && sidecarMode != mode {
+			group.notes = append(group.notes, fmt.Sprintf("Service '%s' shares the network namespace of '%s' and runs in its %s job; deploy.mode '%s' is ignored.", sidecarName, group.name, jobType, sidecarMode))
+		}
+	}
+	return jobType, nil
+}
+
+// nameJobsByType renames the jobs split from one planned job by type: the
+// first type in jobTypeOrder keeps the job name, the others are suffixed with
+// their type.
+func nameJobsByType(jobs []*jobPlan) {
+	firstType := make(map[string]string)
+	for _, job := range jobs {
+		first, ok := firstType[job.name]
+		if !ok || slices.Index(jobTypeOrder, job.jobType) < slices.Index(jobTypeOrder, first) {
+			firstType[job.name] = job.jobType
+		}
+	}
+	for _, job := range jobs {
+		if job.jobType != firstType[job.name] {
+			job.name += "-" + job.jobType
+		}
+	}
+}
+
+// isSystemJobType reports whether jobs of the type run one allocation per
+// eligible node, without a group count.
+func isSystemJobType(jobType string) bool {
+	return jobType == jobTypeSystem || jobType == jobTypeSysbatch
+}
+
+// isBatchJobType reports whether jobs of the type run their tasks to
+// completion.
+func isBatchJobType(jobType string) bool {
+	return jobType == jobTypeBatch || jobType == jobTypeSysbatch
+}
@@ -0,0 +1,97 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const deployModeDockerComposeYAML = `
+name: stack
+services:
+  web:
+    image: web
+    ports:
+      - "80:80"
+  agent:
+    image: agent
+    deploy:
+      mode: global
+      replicas: 2
+  migrate:
+    image: migrate
+    deploy:
+      mode: replicated-job
+      replicas: 3
+  prune:
+    image: prune
+    deploy:
+      mode: global-job
+`
+
+func TestConvertToNomadJobs_DeployMode(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(deployModeDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	for _, name := range []string{"stack.nomad.hcl", "stack-system.nomad.hcl", "stack-batch.nomad.hcl", "stack-sysbatch.nomad.hcl"} {
+		if _, ok := files[name]; !ok || len(files) != 4 {
+			t.Fatalf("Expected one job per job type, got %v", fileNames(files))
+		}
+	}
+
+	checks := map[string]map[string]string{
+		"stack.nomad.hcl": {
+			"service type": `type\s*=\s*"service"`,
+			"web group":    `group "web"\s*\{\s*count\s*=\s*1`,
+		},
+		"stack-system.nomad.hcl": {
+			"system type":      `type\s*=\s*"system"`,
+			"replicas ignored": `group "agent"\s*\{\s*# Service 'agent' runs on every eligible node in a system job; deploy\.replicas is ignored\.`,
+		},
+		"stack-batch.nomad.hcl": {
+			"batch type":  `type\s*=\s*"batch"`,
+			"batch count": `group "migrate"\s*\{\s*count\s*=\s*3`,
+		},
+		"stack-sysbatch.nomad.hcl": {
+			"sysbatch type": `type\s*=\s*"sysbatch"`,
+		},
+	}
+	for fileName, patterns := range checks {
+		for name, pattern := range patterns {
+			if !regexp.MustCompile(pattern).MatchString(files[fileName]) {
+				t.Errorf("%s does not map %s:\n%s", fileName, name, files[fileName])
+			}
+		}
+	}
+	for _, fileName := range []string{"stack-system.nomad.hcl", "stack-sysbatch.nomad.hcl"} {
+		if strings.Contains(files[fileName], "count") {
+			t.Errorf("System jobs should not have a count:\n%s", files[fileName])
+		}
+	}
+}
+
+func TestConvertToNomadJobs_DeployModeSingleType(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs("services:\n  agent:\n    image: agent\n    deploy:\n      mode: global\n", converter.Options{JobName: "agents"})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if !regexp.MustCompile(`job "agents"\s*\{[^}]*type\s*=\s*"system"`).MatchString(files["agents.nomad.hcl"]) {
+		t.Errorf("A job of a single type should keep its name:\n%v", files)
+	}
+
+	if _, err := converter.ConvertToNomadJobs("services:\n  agent:\n    image: agent\n    deploy:\n      mode: everywhere\n", converter.Options{}); err == nil {
+		t.Errorf("Expected an error for an unknown deploy mode")
+	}
+}
+
+func TestConvertToNomadHCL_MultipleJobTypes(t *testing.T) {
+	_, err := converter.ConvertToNomadHCL(deployModeDockerComposeYAML)
+	if err == nil || !strings.Contains(err.Error(), "converts to 4 jobs") || !strings.Contains(err.Error(), "ConvertToNomadJobs") {
+		t.Errorf("Expected an error pointing to ConvertToNomadJobs, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// DiscoveryMode selects how references to other compose services in env
+// values and command args are resolved.
+type DiscoveryMode string
+
+const (
+	// DiscoveryNone leaves references to other services unchanged.
+	DiscoveryNone DiscoveryMode = ""
+	// DiscoveryNomad resolves references in a `template { env = true }` with
+	// nomadService lookups.
+	DiscoveryNomad DiscoveryMode = "nomad"
+	// DiscoveryConsul resolves references in a `template { env = true }` with
+	// Consul service lookups, and registers the services in Consul.
+	DiscoveryConsul DiscoveryMode = "consul"
+	// DiscoveryDNS rewrites references to Consul DNS names, and registers the
+	// services in Consul.
+	DiscoveryDNS DiscoveryMode = "dns"
+)
+
+// discoveryDestination is the file the env template of a task is rendered to.
+const discoveryDestination = "local/services.env"
+
+// consulDNSDomain is the suffix of the Consul DNS names of services.
+const consulDNSDomain = ".service.consul"
+
+// taskDiscovery is the env template resolving the service references of a
+// task.
+type taskDiscovery struct {
+	lines []string // "KEY=value" lines of the env template
+	notes []string
+}
+
+// serviceProvider returns the provider the services of the job are
+// registered with.
+func (r *jobRenderer) serviceProvider() string {
+	switch r.opts.Discovery {
+	case DiscoveryConsul, DiscoveryDNS:
+		return "consul"
+	}
+	return "nomad"
+}
+
+// groupPortSpecs returns the compose port mappings of the named group, those
+// of the owning service first.
+func (r *jobRenderer) groupPortSpecs(groupName string) []string {
+	var members []string
+	for serviceName, owner := range r.groupOf {
+		if owner == groupName && serviceName != groupName {
+			members = append(members, serviceName)
+		}
+	}
+	sort.Strings(members)
+	var specs []string
+	for _, memberName := range append([]string{groupName}, members...) {
+		specs = append(specs, r.dc.Services[memberName].Ports...)
+	}
+	return specs
+}
+
+// serviceDiscovery returns the service with the references to other groups
+// in its env values and command args resolved according to the discovery
+// mode. References in env values move to the env template, and command args
+// reference variables the template defines.
+func (r *jobRenderer) serviceDiscovery(serviceName string, service dockercompose.Service) (dockercompose.Service, taskDiscovery) {
+	var discovery taskDiscovery
+	if r.opts.Discovery == DiscoveryNone {
+		return service, discovery
+	}
+	group := r.groupOf[serviceName]
+	hosts := serviceHosts(r.dc, r.groupOf, service)
+	for host, owner := range hosts {
+		if owner == group {
+			delete(hosts, host)
+		}
+	}
+
+	noted := make(map[string]bool)
+	note := func(text string) {
+		if !noted[text] {
+			noted[text] = true
+			discovery.notes = append(discovery.notes, text)
+		}
+	}
+	definedVars := make(map[string]bool)
+
+	// resolve returns the replacement of a reference. Lookups are template
+	// expressions in env values and template variables in command args.
+	resolve := func(value string, ref hostReference, inArgs bool) string {
+		original := value[ref.start:ref.end]
+		destination := hosts[ref.host]
+		var registered string
+		specs := r.groupPortSpecs(destination)
+		for _, spec := range specs {
+			if registered = containerPortOf(spec); registered != "" {
+				break
+			}
+		}
+		if registered == "" {
+			note(fmt.Sprintf("'%s' registers no service, so references to it cannot be resolved; publish a port.", destination))
+			return original
+		}
+
+		if r.opts.Discovery == DiscoveryDNS {
+			if ref.port == "" {
+				return destination + consulDNSDomain
+			}
+			for _, spec := range specs {
+				if hostPort, ok := staticHostPort(spec); ok && containerPortOf(spec) == ref.port {
+					return destination + consulDNSDomain + ":" + hostPort
+				}
+			}
+			note(fmt.Sprintf("Port %s of '%s' is not a static host port; Consul DNS only resolves the address, so use SRV records or template discovery.", ref.port, destination))
+			return destination + consulDNSDomain + ":" + ref.port
+		}
+
+		if ref.port != "" && ref.port != registered {
+			note(fmt.Sprintf("'%s' is referenced on port %s, but its service is registered on port %s.", destination, ref.port, registered))
+		}
+		function := "nomadService"
+		if r.opts.Discovery == DiscoveryConsul {
+			function = "service"
+		}
+		field, suffix := "{{ .Address }}:{{ .Port }}", "ADDR"
+		if ref.port == "" && ref.start == 0 && ref.end == len(value) {
+			field, suffix = "{{ .Address }}", "HOST"
+		}
+		lookup := fmt.Sprintf(`{{ with %s "%s" }}{{ with index . 0 }}%s{{ end }}{{ end }}`, function, destination, field)
+		if !inArgs {
+			return lookup
+		}
+		name := "SERVICE_" + envName(destination) + "_" + suffix
+		if !definedVars[name] {
+			definedVars[name] = true
+			discovery.lines = append(discovery.lines, name+"="+lookup)
+		}
+		return "${" + name + "}"
+	}
+
+	env := parseKeyValues(service.Environment)
+	rewritten := make(map[string]any, len(env))
+	for _, key := range sortedKeys(env) {
+		value := env[key]
+		if r.opts.Discovery != DiscoveryDNS {
+			// Lookups are written into a double-quoted env file value.
+			value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+		}
+		refs := findHostReferences(value, hosts)
+		switch {
+		case len(refs) == 0 || strings.Contains(value, "\n"):
+			rewritten[key] = env[key]
+		case r.opts.Discovery == DiscoveryDNS:
+			rewritten[key] = replaceHostReferences(value, refs, func(ref hostReference) string { return resolve(value, ref, false) })
+		default:
+			discovery.lines = append(discovery.lines, key+`="`+replaceHostReferences(value, refs, func(ref hostReference) string { return resolve(value, ref, false) })+`"`)
+		}
+	}
+	if len(env) > 0 {
+		service.Environment = rewritten
+	}
+
+	rewriteArg := func(arg string) string {
+		refs := findHostReferences(arg, hosts)
+		return replaceHostReferences(arg, refs, func(ref hostReference) string { return resolve(arg, ref, true) })
+	}
+	service.Entrypoint = rewriteArgs(service.Entrypoint, rewriteArg)
+	service.Command = rewriteArgs(service.Command, rewriteArg)
+	return service, discovery
+}
+
+// rewriteArgs applies rewrite to a compose command or entrypoint, given as a
+// string or a list.
+func rewriteArgs(raw any, rewrite func(string) string) any {
+	switch typed := raw.(type) {
+	case string:
+		return rewrite(typed)
+	case []any:
+		args := make([]any, len(typed))
+		for i, item := range typed {
+			if arg, ok := item.(string); ok {
+				args[i] = rewrite(arg)
+			} else {
+				args[i] = item
+			}
+		}
+		return args
+	}
+	return raw
+}
+
+// staticHostPort returns the host port of a compose port mapping that
+// publishes its container port on a fixed host port.
+func staticHostPort(spec string) (string, bool) {
+	spec, _, _ = strings.Cut(spec, "#")
+	spec, _, _ = strings.Cut(strings.TrimSpace(spec), "/")
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || portNumber(parts[len(parts)-2]) == 0 {
+		return "", false
+	}
+	return parts[len(parts)-2], true
+}
+
+// envName returns a service name as an environment variable name part.
+func envName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			return c
+		}
+		return '_'
+	}, strings.ToUpper(name))
+}
+
+// addDiscoveryTemplate writes the env template resolving the service
+// references of a task.
+func addDiscoveryTemplate(taskBody *hclwrite.Body, discovery taskDiscovery) {
+	templateBody := taskBody.AppendNewBlock("template", nil).Body()
+	templateBody.SetAttributeRaw("data", heredocTokens(discovery.lines))
+	templateBody.SetAttributeValue("destination", cty.StringVal(discoveryDestination))
+	templateBody.SetAttributeValue("env", cty.True)
+}
+
+// heredocTokens returns the tokens of a heredoc string holding the given
+// lines, with HCL template sequences escaped.
+func heredocTokens(lines []string) hclwrite.Tokens {
+	escaper := strings.NewReplacer("${", "$${", "%{", "%%{")
+	tokens := hclwrite.Tokens{{Type: hclsyntax.TokenOHeredoc, Bytes: []byte("<<EOT\n")}}
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenStringLit, Bytes: []byte(escaper.Replace(line) + "\n")})
+	}
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCHeredoc, Bytes: []byte("EOT")})
+}
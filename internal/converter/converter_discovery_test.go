@@ -0,0 +1,106 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const discoveryDockerComposeYAML = `
+services:
+  web:
+    image: web
+    ports:
+      - "8080:80"
+    environment:
+      DATABASE_URL: postgres://app@db:5432/app
+      CACHE_HOST: cache
+      GREETING: hello db
+    command: ["serve", "--cache", "cache:6379", "--search", "search:9200"]
+  db:
+    image: postgres
+    ports:
+      - "5432"
+  cache:
+    image: redis
+    ports:
+      - "6379:6379"
+  search:
+    image: elasticsearch
+`
+
+func TestConvertToNomadJobs_DiscoveryTemplate(t *testing.T) {
+	for mode, function := range map[converter.DiscoveryMode]string{converter.DiscoveryNomad: "nomadService", converter.DiscoveryConsul: "service"} {
+		files, err := converter.ConvertToNomadJobs(discoveryDockerComposeYAML, converter.Options{Discovery: mode})
+		if err != nil {
+			t.Fatalf("ConvertToNomadJobs failed: %v", err)
+		}
+		hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+		lookup := func(service, field string) string {
+			return regexp.QuoteMeta(`{{ with ` + function + ` "` + service + `" }}{{ with index . 0 }}` + field + `{{ end }}{{ end }}`)
+		}
+		checks := map[string]string{
+			"url":             `DATABASE_URL="postgres://app@` + lookup("db", "{{ .Address }}:{{ .Port }}") + `/app"`,
+			"host":            `CACHE_HOST="` + lookup("cache", "{{ .Address }}") + `"`,
+			"arg variable":    `SERVICE_CACHE_ADDR=` + lookup("cache", "{{ .Address }}:{{ .Port }}"),
+			"args":            `args\s*=\s*\["--cache", "\$\$\{SERVICE_CACHE_ADDR\}", "--search", "search:9200"\]`,
+			"template":        `destination\s*=\s*"local/services\.env"\s*env\s*=\s*true`,
+			"plain words":     `env\s*\{\s*GREETING\s*=\s*"hello db"\s*\}`,
+			"no service note": `# 'search' registers no service, so references to it cannot be resolved`,
+		}
+		for name, pattern := range checks {
+			if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+				t.Errorf("%s discovery does not map %s:\n%s", mode, name, hclOutput)
+			}
+		}
+		wantProvider := `provider = "nomad"`
+		if mode == converter.DiscoveryConsul {
+			wantProvider = `provider = "consul"`
+		}
+		if !strings.Contains(hclOutput, wantProvider) {
+			t.Errorf("%s discovery should register services with %s:\n%s", mode, wantProvider, hclOutput)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_DiscoveryDNS(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(discoveryDockerComposeYAML, converter.Options{Discovery: converter.DiscoveryDNS})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	checks := map[string]string{
+		"url":          `DATABASE_URL\s*=\s*"postgres://app@db\.service\.consul:5432/app"`,
+		"host":         `CACHE_HOST\s*=\s*"cache\.service\.consul"`,
+		"static port":  `"--cache", "cache\.service\.consul:6379"`,
+		"dynamic port": `# Port 5432 of 'db' is not a static host port`,
+		"provider":     `provider\s*=\s*"consul"`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("DNS discovery does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Contains(hclOutput, "template {") {
+		t.Errorf("DNS discovery should not need templates:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_DiscoveryDefault(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(discoveryDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if hclOutput := files["my-docker-compose-job.nomad.hcl"]; !strings.Contains(hclOutput, `"postgres://app@db:5432/app"`) {
+		t.Errorf("References should be left unchanged without a discovery mode:\n%s", hclOutput)
+	}
+	if _, err := converter.ConvertToNomadJobs(discoveryDockerComposeYAML, converter.Options{Discovery: "zookeeper"}); err == nil {
+		t.Errorf("Expected an error for an unknown discovery mode")
+	}
+}
@@ -0,0 +1,408 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// TaskDriver selects the Nomad task driver running the converted services.
+type TaskDriver string
+
+const (
+	// DriverDocker runs services with Nomad's built-in docker driver. It is
+	// used when no driver is selected.
+	DriverDocker TaskDriver = "docker"
+	// DriverPodman runs services with the nomad-driver-podman plugin.
+	DriverPodman TaskDriver = "podman"
+)
+
+// taskDriver writes the driver specific parts of a task. The core mapping
+// derives the values from a compose service and leaves the shape of the
+// driver config to the driver, so drivers can be added without touching it.
+type taskDriver interface {
+	// name returns the value of the task's driver attribute.
+	name() string
+	// image returns the image reference the driver runs for a compose image.
+	image(image string) string
+	// setAuth writes or notes the registry credentials needed to pull the
+	// image, which compose leaves to the engine's login.
+	setAuth(config *hclwrite.Body, image string)
+	// setPorts writes the labels of the group ports the task exposes.
+	setPorts(config *hclwrite.Body, labels []string)
+	// setVolumes writes the host path binds ("source:destination[:ro]") of the task.
+	setVolumes(config *hclwrite.Body, binds []string)
+	// setCommand writes the command of the task and its arguments. The
+	// command is empty when the image default is kept.
+	setCommand(config *hclwrite.Body, command string, args []string)
+	// setSecurity writes the security settings of the task, noting the client
+	// plugin configuration they require in comments.
+	setSecurity(config *hclwrite.Body, sec taskSecurity)
+	// setTuning writes the kernel and process settings of the task.
+	setTuning(config *hclwrite.Body, tuning taskTuning)
+	// setIdentity writes the hostname, DNS settings and host entries of the task.
+	setIdentity(config *hclwrite.Body, identity taskIdentity)
+	// setLogging writes the logging driver of the task and its options.
+	setLogging(config *hclwrite.Body, logging taskLogging)
+	// setLabels writes the container labels of the task.
+	setLabels(config *hclwrite.Body, labels map[string]string)
+}
+
+// taskDrivers lists the supported task drivers.
+var taskDrivers = map[TaskDriver]taskDriver{
+	DriverDocker: dockerDriver{},
+	DriverPodman: podmanDriver{},
+}
+
+// driverFor returns the task driver selected by the options.
+func driverFor(driver TaskDriver) (taskDriver, error) {
+	if driver == "" {
+		driver = DriverDocker
+	}
+	d, ok := taskDrivers[driver]
+	if !ok {
+		return nil, fmt.Errorf("unknown task driver %q", driver)
+	}
+	return d, nil
+}
+
+// dockerDriver writes the config of Nomad's docker driver.
+type dockerDriver struct{}
+
+func (dockerDriver) name() string {
+	return string(DriverDocker)
+}
+
+func (dockerDriver) image(image string) string {
+	return image
+}
+
+// setAuth writes nothing: the docker driver pulls with the credentials of the
+// auth config or helper set in the docker plugin config of the clients, the
+// counterpart of `docker login`.
+func (dockerDriver) setAuth(config *hclwrite.Body, image string) {}
+
+func (dockerDriver) setPorts(config *hclwrite.Body, labels []string) {
+	config.SetAttributeValue("ports", stringListVal(labels))
+}
+
+func (dockerDriver) setVolumes(config *hclwrite.Body, binds []string) {
+	config.SetAttributeValue("volumes", stringListVal(binds))
+}
+
+func (dockerDriver) setCommand(config *hclwrite.Body, command string, args []string) {
+	if command != "" {
+		config.SetAttributeValue("command", cty.StringVal(command))
+	}
+	if len(args) > 0 {
+		config.SetAttributeValue("args", stringListVal(args))
+	}
+}
+
+func (dockerDriver) setSecurity(config *hclwrite.Body, sec taskSecurity) {
+	if sec.privileged {
+		config.AppendUnstructuredTokens(portutils.CreateCommentTokens("Privileged containers require allow_privileged = true in the docker plugin config of the Nomad clients."))
+		config.SetAttributeValue("privileged", cty.True)
+	}
+	if len(sec.capAdd) > 0 {
+		if caps := sec.restrictedCaps(); len(caps) > 0 {
+			config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Capabilities %s must be listed in allow_caps of the docker plugin config of the Nomad clients.", strings.Join(caps, ", "))))
+		}
+		config.SetAttributeValue("cap_add", stringListVal(sec.capAdd))
+	}
+	if len(sec.capDrop) > 0 {
+		config.SetAttributeValue("cap_drop", stringListVal(sec.capDrop))
+	}
+	if len(sec.devices) > 0 {
+		devices := make([]cty.Value, len(sec.devices))
+		for i, device := range sec.devices {
+			devices[i] = cty.ObjectVal(map[string]cty.Value{
+				"host_path":          cty.StringVal(device.host),
+				"container_path":     cty.StringVal(device.container),
+				"cgroup_permissions": cty.StringVal(device.permissions),
+			})
+		}
+		config.SetAttributeValue("devices", cty.ListVal(devices))
+	}
+	if len(sec.securityOpt) > 0 {
+		config.SetAttributeValue("security_opt", stringListVal(sec.securityOpt))
+	}
+	if sec.readOnly {
+		config.SetAttributeValue("readonly_rootfs", cty.True)
+	}
+	if len(sec.groupAdd) > 0 {
+		config.SetAttributeValue("group_add", stringListVal(sec.groupAdd))
+	}
+	if sec.usernsMode != "" {
+		config.SetAttributeValue("userns_mode", cty.StringVal(sec.usernsMode))
+	}
+}
+
+func (dockerDriver) setTuning(config *hclwrite.Body, tuning taskTuning) {
+	if len(tuning.sysctls) > 0 {
+		config.SetAttributeValue("sysctl", stringMapVal(tuning.sysctls))
+	}
+	if tuning.shmSize > 0 {
+		config.SetAttributeValue("shm_size", cty.NumberIntVal(tuning.shmSize))
+	}
+	if tuning.pidsLimit != nil {
+		config.SetAttributeValue("pids_limit", cty.NumberIntVal(*tuning.pidsLimit))
+	}
+	if tuning.ipcMode != "" {
+		config.SetAttributeValue("ipc_mode", cty.StringVal(tuning.ipcMode))
+	}
+	if tuning.pidMode != "" {
+		config.SetAttributeValue("pid_mode", cty.StringVal(tuning.pidMode))
+	}
+	if tuning.init {
+		config.SetAttributeValue("init", cty.True)
+	}
+	if len(tuning.ulimits) > 0 {
+		addUlimitBlock(config, tuning.ulimits)
+	}
+}
+
+func (dockerDriver) setIdentity(config *hclwrite.Body, identity taskIdentity) {
+	if identity.networkMode != "" {
+		config.SetAttributeValue("network_mode", cty.StringVal(identity.networkMode))
+	}
+	if identity.hostname != "" {
+		config.SetAttributeValue("hostname", cty.StringVal(identity.hostname))
+	}
+	if identity.macAddress != "" {
+		config.SetAttributeValue("mac_address", cty.StringVal(identity.macAddress))
+	}
+	if len(identity.dns.servers) > 0 {
+		config.SetAttributeValue("dns_servers", stringListVal(identity.dns.servers))
+	}
+	if len(identity.dns.searches) > 0 {
+		config.SetAttributeValue("dns_search_domains", stringListVal(identity.dns.searches))
+	}
+	if len(identity.dns.options) > 0 {
+		config.SetAttributeValue("dns_options", stringListVal(identity.dns.options))
+	}
+	if len(identity.extraHosts) > 0 {
+		config.SetAttributeValue("extra_hosts", stringListVal(identity.extraHosts))
+	}
+}
+
+func (dockerDriver) setLogging(config *hclwrite.Body, logging taskLogging) {
+	loggingType := logging.driver
+	if loggingType == "" {
+		loggingType = "json-file"
+	}
+	loggingBody := config.AppendNewBlock("logging", nil).Body()
+	loggingBody.SetAttributeValue("type", cty.StringVal(loggingType))
+	if len(logging.options) > 0 {
+		loggingConfigBody := loggingBody.AppendNewBlock("config", nil).Body()
+		for _, key := range logging.sortedOptionKeys() {
+			loggingConfigBody.SetAttributeValue(key, cty.StringVal(logging.options[key]))
+		}
+	}
+}
+
+func (dockerDriver) setLabels(config *hclwrite.Body, labels map[string]string) {
+	config.SetAttributeValue("labels", stringMapVal(labels))
+}
+
+// podmanDriver writes the config of the nomad-driver-podman plugin. Its
+// config mostly mirrors the docker driver's.
+type podmanDriver struct{}
+
+func (podmanDriver) name() string {
+	return string(DriverPodman)
+}
+
+// image fully qualifies short image names such as "nginx" as
+// "docker.io/library/nginx", since podman resolves short names through the
+// client's registries.conf rather than defaulting to Docker Hub.
+func (podmanDriver) image(image string) string {
+	if image == "" || strings.Contains(image, "://") {
+		return image
+	}
+	first, _, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+	if !found {
+		image = "library/" + image
+	}
+	return "docker.io/" + image
+}
+
+// setAuth notes how images from registries other than Docker Hub are pulled.
+// The podman driver uses the credentials of the podman service on the client
+// unless the task has an auth block, whose username and password compose
+// cannot provide.
+func (podmanDriver) setAuth(config *hclwrite.Body, image string) {
+	registry, _, found := strings.Cut(image, "/")
+	if !found || registry == "docker.io" || registry == "localhost" || !strings.ContainsAny(registry, ".:") {
+		return
+	}
+	config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Images from %s are pulled with the podman login of the Nomad clients; add an auth block with username and password if the registry is private.", registry)))
+}
+
+// setPorts writes the port labels of the task. The podman driver takes the
+// same list of group port labels as the docker driver.
+func (podmanDriver) setPorts(config *hclwrite.Body, labels []string) {
+	config.SetAttributeValue("ports", stringListVal(labels))
+}
+
+// setVolumes writes the host path binds of the task. The podman driver takes
+// the same "source:destination[:ro]" strings as the docker driver; SELinux
+// relabeling is set by selinuxlabel in its plugin config rather than per bind.
+func (podmanDriver) setVolumes(config *hclwrite.Body, binds []string) {
+	config.SetAttributeValue("volumes", stringListVal(binds))
+}
+
+// setCommand writes the command and arguments of the task. The podman driver
+// takes the command as a string and the arguments as a list, like the docker
+// driver.
+func (podmanDriver) setCommand(config *hclwrite.Body, command string, args []string) {
+	if command != "" {
+		config.SetAttributeValue("command", cty.StringVal(command))
+	}
+	if len(args) > 0 {
+		config.SetAttributeValue("args", stringListVal(args))
+	}
+}
+
+// setTuning writes the podman equivalents of the tuning settings. The podman
+// driver takes shm_size as a size string and has no ipc, pid or pids limit options.
+func (podmanDriver) setTuning(config *hclwrite.Body, tuning taskTuning) {
+	if len(tuning.sysctls) > 0 {
+		config.SetAttributeValue("sysctl", stringMapVal(tuning.sysctls))
+	}
+	if tuning.shmSize > 0 {
+		config.SetAttributeValue("shm_size", cty.StringVal(formatByteSize(tuning.shmSize)))
+	}
+	var unsupported []string
+	if tuning.pidsLimit != nil {
+		unsupported = append(unsupported, "pids_limit")
+	}
+	if tuning.ipcMode != "" {
+		unsupported = append(unsupported, "ipc")
+	}
+	if tuning.pidMode != "" {
+		unsupported = append(unsupported, "pid")
+	}
+	if len(unsupported) > 0 {
+		config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("The podman driver does not support %s; they are not converted.", strings.Join(unsupported, ", "))))
+	}
+	if tuning.init {
+		config.SetAttributeValue("init", cty.True)
+	}
+	if len(tuning.ulimits) > 0 {
+		addUlimitBlock(config, tuning.ulimits)
+	}
+}
+
+// setIdentity writes the podman equivalents of the identity settings. The
+// podman driver has no DNS or MAC address options.
+func (podmanDriver) setIdentity(config *hclwrite.Body, identity taskIdentity) {
+	if identity.networkMode != "" {
+		config.SetAttributeValue("network_mode", cty.StringVal(identity.networkMode))
+	}
+	if identity.hostname != "" {
+		config.SetAttributeValue("hostname", cty.StringVal(identity.hostname))
+	}
+	var unsupported []string
+	if identity.macAddress != "" {
+		unsupported = append(unsupported, "mac_address")
+	}
+	if !identity.dns.isEmpty() {
+		unsupported = append(unsupported, "dns")
+	}
+	if len(unsupported) > 0 {
+		config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("The podman driver does not support %s; they are not converted.", strings.Join(unsupported, ", "))))
+	}
+	if len(identity.extraHosts) > 0 {
+		config.SetAttributeValue("extra_hosts", stringListVal(identity.extraHosts))
+	}
+}
+
+// setLogging writes the podman logging driver. The podman driver only
+// supports Nomad's own log collection and journald.
+func (podmanDriver) setLogging(config *hclwrite.Body, logging taskLogging) {
+	switch {
+	case logging.isJSONFile():
+		config.AppendUnstructuredTokens(portutils.CreateCommentTokens("Logs are collected by Nomad; json-file rotation options are mapped to the logs block."))
+	case logging.driver == "journald":
+		loggingBody := config.AppendNewBlock("logging", nil).Body()
+		loggingBody.SetAttributeValue("driver", cty.StringVal("journald"))
+		if len(logging.options) > 0 {
+			loggingBody.SetAttributeValue("options", stringMapVal(logging.options))
+		}
+	default:
+		config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("The podman driver does not support the %s logging driver; logs are collected by Nomad.", logging.driver)))
+	}
+}
+
+// setLabels writes the container labels of the task, a map as for docker.
+func (podmanDriver) setLabels(config *hclwrite.Body, labels map[string]string) {
+	config.SetAttributeValue("labels", stringMapVal(labels))
+}
+
+// setSecurity writes the podman equivalents of the security settings. Devices
+// are given in their short "host:container:permissions" form.
+func (podmanDriver) setSecurity(config *hclwrite.Body, sec taskSecurity) {
+	if sec.privileged {
+		config.SetAttributeValue("privileged", cty.True)
+	}
+	if len(sec.capAdd) > 0 {
+		if caps := sec.restrictedCaps(); len(caps) > 0 {
+			config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Capabilities %s must be listed in allow_caps of the podman plugin config of the Nomad clients.", strings.Join(caps, ", "))))
+		}
+		config.SetAttributeValue("cap_add", stringListVal(sec.capAdd))
+	}
+	if len(sec.capDrop) > 0 {
+		config.SetAttributeValue("cap_drop", stringListVal(sec.capDrop))
+	}
+	if len(sec.devices) > 0 {
+		devices := make([]string, len(sec.devices))
+		for i, device := range sec.devices {
+			devices[i] = device.host + ":" + device.container + ":" + device.permissions
+		}
+		config.SetAttributeValue("devices", stringListVal(devices))
+	}
+	if len(sec.securityOpt) > 0 {
+		config.SetAttributeValue("security_opt", stringListVal(sec.securityOpt))
+	}
+	if sec.readOnly {
+		config.SetAttributeValue("readonly_rootfs", cty.True)
+	}
+	if len(sec.groupAdd) > 0 {
+		config.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("The podman driver does not support group_add; add groups %s to the image user instead.", strings.Join(sec.groupAdd, ", "))))
+	}
+	if sec.usernsMode != "" {
+		config.SetAttributeValue("userns", cty.StringVal(sec.usernsMode))
+	}
+}
+
+// stringListVal converts a list of strings into a cty list value.
+func stringListVal(values []string) cty.Value {
+	if len(values) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+	ctyValues := make([]cty.Value, len(values))
+	for i, value := range values {
+		ctyValues[i] = cty.StringVal(value)
+	}
+	return cty.ListVal(ctyValues)
+}
+
+// stringMapVal converts a map of strings into a cty map value.
+func stringMapVal(values map[string]string) cty.Value {
+	if len(values) == 0 {
+		return cty.MapValEmpty(cty.String)
+	}
+	ctyValues := make(map[string]cty.Value, len(values))
+	for key, value := range values {
+		ctyValues[key] = cty.StringVal(value)
+	}
+	return cty.MapVal(ctyValues)
+}
@@ -0,0 +1,69 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+func TestConvertToNomadJobs_PodmanDriver(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{Driver: converter.DriverPodman, Lint: true})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	if strings.Contains(hclOutput, `driver = "docker"`) {
+		t.Errorf("HCL output should not use the docker driver:\n%s", hclOutput)
+	}
+	checks := map[string]string{
+		"driver":      `task "web"\s*\{\s*driver\s*=\s*"podman"`,
+		"short image": `image\s*=\s*"docker\.io/library/nginx:latest"`,
+		"user image":  `image\s*=\s*"docker\.io/library/myapi:1\.0"`,
+		"ports":       `ports\s*=\s*\["http", "https"\]`,
+		"volumes":     `volumes\s*=\s*\["\./nginx\.conf:/etc/nginx/nginx\.conf:ro"\]`,
+		"command":     `command\s*=\s*"/app/start"\s*args\s*=\s*\["--port", "3000"\]`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not match the podman %s:\n%s", name, hclOutput)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_PodmanImages(t *testing.T) {
+	yaml := `
+services:
+  hub:
+    image: team/app:2
+  registry:
+    image: ghcr.io/team/app:2
+  local:
+    image: localhost/app
+`
+	files, err := converter.ConvertToNomadJobs(yaml, converter.Options{Driver: converter.DriverPodman})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+	for _, image := range []string{"docker.io/team/app:2", "ghcr.io/team/app:2", "localhost/app"} {
+		if !strings.Contains(hclOutput, `image = "`+image+`"`) {
+			t.Errorf("HCL output does not reference image %s:\n%s", image, hclOutput)
+		}
+	}
+	authNote := "# Images from ghcr.io are pulled with the podman login of the Nomad clients; add an auth block with username and password if the registry is private."
+	if strings.Count(hclOutput, "are pulled with the podman login") != 1 || !regexp.MustCompile(`task "registry"[\s\S]*?`+regexp.QuoteMeta(authNote)).MatchString(hclOutput) {
+		t.Errorf("Expected a credentials note for the ghcr.io image only:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_UnknownDriver(t *testing.T) {
+	_, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{Driver: "lxc"})
+	if err == nil || !strings.Contains(err.Error(), "unknown task driver") {
+		t.Errorf("Expected an unknown driver error, got %v", err)
+	}
+}
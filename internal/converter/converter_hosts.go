@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"strings"
+)
+
+// hostReference is a reference to a compose service by hostname in a value,
+// such as "db" in "postgres://db:5432/app".
+type hostReference struct {
+	start, end int    // Byte span of the hostname and port
+	host       string // The hostname as written, a service name or link alias
+	port       string // Empty when the reference has no port
+}
+
+// isHostChar reports whether c can be part of a hostname.
+func isHostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.'
+}
+
+// findHostReferences returns the references to the given hosts in a value. A
+// hostname followed by a port counts wherever it stands; a bare hostname only
+// when it is the whole value or the host of a URL, so that words which happen
+// to match a service name are left alone.
+func findHostReferences(value string, hosts map[string]string) []hostReference {
+	var refs []hostReference
+	for i := 0; i < len(value); i++ {
+		if i > 0 && isHostChar(value[i-1]) {
+			continue
+		}
+		end := i
+		for end < len(value) && isHostChar(value[end]) {
+			end++
+		}
+		host := value[i:end]
+		if _, ok := hosts[host]; !ok {
+			continue
+		}
+
+		ref := hostReference{start: i, end: end, host: host}
+		if end < len(value) && value[end] == ':' {
+			portEnd := end + 1
+			for portEnd < len(value) && value[portEnd] >= '0' && value[portEnd] <= '9' {
+				portEnd++
+			}
+			if portEnd > end+1 {
+				ref.end, ref.port = portEnd, value[end+1:portEnd]
+			}
+		}
+		urlHost := strings.HasSuffix(value[:i], "//") || strings.HasSuffix(value[:i], "@")
+		if ref.port == "" && !urlHost && (i > 0 || end < len(value)) {
+			continue
+		}
+		refs = append(refs, ref)
+		i = ref.end - 1
+	}
+	return refs
+}
+
+// replaceHostReferences returns the value with every reference replaced by
+// the result of replace.
+func replaceHostReferences(value string, refs []hostReference, replace func(hostReference) string) string {
+	var builder strings.Builder
+	last := 0
+	for _, ref := range refs {
+		builder.WriteString(value[last:ref.start])
+		builder.WriteString(replace(ref))
+		last = ref.end
+	}
+	builder.WriteString(value[last:])
+	return builder.String()
+}
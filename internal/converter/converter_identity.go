@@ -0,0 +1,179 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// dnsConfig holds the DNS settings of a container or group network.
+type dnsConfig struct {
+	servers  []string
+	searches []string
+	options  []string
+}
+
+// isEmpty reports whether no DNS setting is set.
+func (d dnsConfig) isEmpty() bool {
+	return len(d.servers) == 0 && len(d.searches) == 0 && len(d.options) == 0
+}
+
+// equal reports whether both configs hold the same settings.
+func (d dnsConfig) equal(other dnsConfig) bool {
+	return strings.Join(d.servers, ",") == strings.Join(other.servers, ",") &&
+		strings.Join(d.searches, ",") == strings.Join(other.searches, ",") &&
+		strings.Join(d.options, ",") == strings.Join(other.options, ",")
+}
+
+// taskIdentity holds the network mode, hostname, DNS and host entries of a
+// compose service.
+type taskIdentity struct {
+	networkMode string // "host" or "none"; empty for the default network
+	hostname    string
+	macAddress  string
+	dns         dnsConfig
+	extraHosts  []string // "host:ip"
+}
+
+// serviceDNS returns the DNS settings of a compose service.
+func serviceDNS(service dockercompose.Service) dnsConfig {
+	return dnsConfig{
+		servers:  stringList(service.DNS, false),
+		searches: stringList(service.DNSSearch, false),
+		options:  service.DNSOpt,
+	}
+}
+
+// serviceIdentity collects the identity settings of a compose service. Keys
+// without a driver equivalent, or that conflict with host networking, are
+// returned as notes.
+func serviceIdentity(service dockercompose.Service) (taskIdentity, []string) {
+	identity := taskIdentity{
+		networkMode: driverNetworkMode(service),
+		hostname:    service.Hostname,
+		macAddress:  service.MacAddress,
+		dns:         serviceDNS(service),
+		extraHosts:  parseExtraHosts(service.ExtraHosts),
+	}
+	var notes []string
+	if service.Domainname != "" {
+		notes = append(notes, fmt.Sprintf("domainname '%s' has no Nomad equivalent; use a fully qualified hostname or dns_search instead.", service.Domainname))
+	}
+	if identity.networkMode == networkModeHost {
+		var conflicting []string
+		if identity.hostname != "" {
+			conflicting = append(conflicting, "hostname")
+		}
+		if identity.macAddress != "" {
+			conflicting = append(conflicting, "mac_address")
+		}
+		if !identity.dns.isEmpty() {
+			conflicting = append(conflicting, "dns")
+		}
+		if len(conflicting) > 0 {
+			notes = append(notes, fmt.Sprintf("Host networking uses the host's %s; the service's settings are dropped.", strings.Join(conflicting, ", ")))
+		}
+		identity.hostname, identity.macAddress, identity.dns = "", "", dnsConfig{}
+	}
+	return identity, notes
+}
+
+// parseExtraHosts parses compose extra_hosts, given as a list of "host:ip" or
+// "host=ip" entries or as a map of host to one or more IPs, into the drivers'
+// "host:ip" form.
+func parseExtraHosts(raw any) []string {
+	var hosts []string
+	switch typed := raw.(type) {
+	case []any:
+		for _, item := range typed {
+			entry, ok := item.(string)
+			if !ok {
+				continue
+			}
+			// Split at the first separator, as IPv6 addresses contain colons.
+			if i := strings.IndexAny(entry, "=:"); i > 0 {
+				hosts = append(hosts, entry[:i]+":"+entry[i+1:])
+			}
+		}
+	case map[string]any:
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, ip := range stringList(typed[name], false) {
+				hosts = append(hosts, name+":"+ip)
+			}
+		}
+	}
+	return hosts
+}
+
+// addIdentity writes the identity settings of a service to the task config.
+// In a shared network the hostname and DNS belong to the group network and
+// are left out of the task.
+func (r *jobRenderer) addIdentity(configBody *hclwrite.Body, service dockercompose.Service, sharedNetwork bool) {
+	identity, notes := serviceIdentity(service)
+	if sharedNetwork {
+		identity.hostname = ""
+		identity.dns = dnsConfig{}
+	}
+	for _, note := range notes {
+		configBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	r.driver.setIdentity(configBody, identity)
+}
+
+// addNetworkIdentity writes the hostname and DNS settings of a group whose
+// services share a network namespace to its network block. The settings of
+// the owning service win; conflicting settings of other members are noted.
+func (r *jobRenderer) addNetworkIdentity(groupBody *hclwrite.Body, networkBody *hclwrite.Body, group *groupPlan) {
+	var hostname, hostnameOwner string
+	var dns dnsConfig
+	var dnsOwner string
+	for _, memberName := range group.members() {
+		member := r.dc.Services[memberName]
+		switch {
+		case member.Hostname == "":
+		case hostname == "":
+			hostname, hostnameOwner = member.Hostname, memberName
+		case member.Hostname != hostname:
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("hostname '%s' of service '%s' conflicts with '%s' of service '%s' in the shared network; using '%s'.", member.Hostname, memberName, hostname, hostnameOwner, hostname)))
+		}
+
+		memberDNS := serviceDNS(member)
+		switch {
+		case memberDNS.isEmpty():
+		case dns.isEmpty():
+			dns, dnsOwner = memberDNS, memberName
+		case !memberDNS.equal(dns):
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("DNS settings of service '%s' conflict with those of service '%s' in the shared network; using the settings of '%s'.", memberName, dnsOwner, dnsOwner)))
+		}
+	}
+
+	if hostname != "" || !dns.isEmpty() {
+		networkBody.AppendNewline()
+	}
+	if hostname != "" {
+		networkBody.SetAttributeValue("hostname", cty.StringVal(hostname))
+	}
+	if !dns.isEmpty() {
+		dnsBody := networkBody.AppendNewBlock("dns", nil).Body()
+		if len(dns.servers) > 0 {
+			dnsBody.SetAttributeValue("servers", stringListVal(dns.servers))
+		}
+		if len(dns.searches) > 0 {
+			dnsBody.SetAttributeValue("searches", stringListVal(dns.searches))
+		}
+		if len(dns.options) > 0 {
+			dnsBody.SetAttributeValue("options", stringListVal(dns.options))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const identityDockerComposeYAML = `
+services:
+  web:
+    image: nginx
+    hostname: web.local
+    domainname: example.com
+    mac_address: 02:42:ac:11:00:02
+    dns: 1.1.1.1
+    dns_search: [example.com]
+    dns_opt: [ndots:2]
+    extra_hosts:
+      - "db:10.0.0.2"
+      - "v6=::1"
+  vpn:
+    image: qmcgaw/gluetun
+    hostname: vpn
+    dns: [9.9.9.9]
+    ports:
+      - "8080:8080"
+    extra_hosts:
+      gateway: 10.0.0.1
+  app:
+    image: app
+    network_mode: service:vpn
+    hostname: app
+    dns: [8.8.8.8]
+`
+
+func TestConvertToNomadHCL_Identity(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(identityDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"hostname":           `task "web"[\s\S]*?hostname\s*=\s*"web\.local"`,
+		"mac_address":        `mac_address\s*=\s*"02:42:ac:11:00:02"`,
+		"dns_servers":        `dns_servers\s*=\s*\["1\.1\.1\.1"\]`,
+		"dns_search_domains": `dns_search_domains\s*=\s*\["example\.com"\]`,
+		"dns_options":        `dns_options\s*=\s*\["ndots:2"\]`,
+		"extra_hosts list":   `extra_hosts\s*=\s*\["db:10\.0\.0\.2", "v6:::1"\]`,
+		"extra_hosts map":    `extra_hosts\s*=\s*\["gateway:10\.0\.0\.1"\]`,
+		"domainname note":    `# domainname 'example\.com' has no Nomad equivalent`,
+		"network hostname":   `network\s*\{\s*mode\s*=\s*"bridge"[\s\S]*?hostname\s*=\s*"vpn"\s*dns\s*\{\s*servers\s*=\s*\["9\.9\.9\.9"\]\s*\}`,
+		"hostname conflict":  `# hostname 'app' of service 'app' conflicts with 'vpn' of service 'vpn'`,
+		"dns conflict":       `# DNS settings of service 'app' conflict with those of service 'vpn'`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+
+	sharedGroup := regexp.MustCompile(`group "vpn"[\s\S]*?network \{`).FindString(hclOutput)
+	if regexp.MustCompile(`dns_servers|hostname\s*=`).MatchString(sharedGroup) {
+		t.Errorf("Tasks in a shared network should leave hostname and DNS to the group network:\n%s", hclOutput)
+	}
+}
@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/jobspec2"
+)
+
+// jobPayload is the request body of the Nomad job register endpoint.
+type jobPayload struct {
+	Job *api.Job
+}
+
+// parseJobHCL parses a generated job with Nomad's own jobspec parser, taking
+// variable values from the given var file content.
+func parseJobHCL(fileName string, hclOutput string, varsOutput string) (*api.Job, error) {
+	job, err := jobspec2.ParseWithConfig(&jobspec2.ParseConfig{
+		Path:       fileName,
+		Body:       []byte(hclOutput),
+		VarContent: varsOutput,
+		AllowFS:    false,
+		Strict:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing generated job %s: %w", fileName, err)
+	}
+	return job, nil
+}
+
+// renderJobJSON converts a generated HCL job to its API JSON payload. The JSON
+// is derived from the HCL through Nomad's jobspec parser, the same path
+// `nomad job run -output` takes, so both formats always describe the same job.
+// The round-trip drops the HCL comments carrying the conversion notes.
+func renderJobJSON(fileName string, hclOutput string, varsOutput string) (string, error) {
+	job, err := parseJobHCL(fileName, hclOutput, varsOutput)
+	if err != nil {
+		return "", err
+	}
+	out, err := json.MarshalIndent(jobPayload{Job: job}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error writing JSON: %w", err)
+	}
+	return string(out) + "\n", nil
+}
@@ -0,0 +1,63 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+func TestConvertToNomadJobs_JSON(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{Format: converter.FormatJSON})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	jsonOutput, ok := files["my-docker-compose-job.json"]
+	if !ok {
+		t.Fatalf("Expected a JSON job file, got %v", fileNames(files))
+	}
+
+	var payload struct {
+		Job *api.Job
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &payload); err != nil {
+		t.Fatalf("JSON output is not a job payload: %v", err)
+	}
+	job := payload.Job
+	if job == nil || job.ID == nil || *job.ID != "my-docker-compose-job" {
+		t.Fatalf("Expected job ID my-docker-compose-job in payload:\n%s", jsonOutput)
+	}
+	if len(job.TaskGroups) != 2 {
+		t.Fatalf("Expected 2 task groups, got %d", len(job.TaskGroups))
+	}
+
+	web := job.LookupTaskGroup("web")
+	if web == nil || web.Count == nil || *web.Count != 2 {
+		t.Fatalf("Expected web group with count 2")
+	}
+	task := web.Tasks[0]
+	if task.Name != "web" || task.Driver != "docker" || task.Config["image"] != "nginx:latest" {
+		t.Errorf("Expected web task running nginx:latest with the docker driver, got %+v", task)
+	}
+	if task.Env["NGINX_HOST"] != "example.com" {
+		t.Errorf("Expected NGINX_HOST env in web task, got %v", task.Env)
+	}
+	if len(web.Networks) != 1 || len(web.Networks[0].ReservedPorts) != 2 {
+		t.Errorf("Expected web group to reserve its two static ports")
+	}
+	if len(web.Services) != 1 || web.Services[0].Name != "web" {
+		t.Errorf("Expected web group to register the web service")
+	}
+}
+
+func TestConvertToNomadJobs_UnknownFormat(t *testing.T) {
+	_, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{Format: "yaml"})
+	if err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Errorf("Expected unknown output format error, but got: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// LintNomadHCL parses a Nomad job with Nomad's jobspec parser and validates
+// it, reporting problems as error diagnostics. Native builds run the job
+// validation of Nomad's server, which rejects the same jobs `nomad job run`
+// would. The WASM build leaves the server out and only checks that the job
+// has an ID, a known type and groups of tasks with a driver.
+func LintNomadHCL(fileName string, hclInput string) hcl.Diagnostics {
+	return LintNomadHCLWithVars(fileName, hclInput, "")
+}
+
+// LintNomadHCLWithVars lints a Nomad job like LintNomadHCL, taking
+// the values of its variables from the given var file content.
+func LintNomadHCLWithVars(fileName string, hclInput string, varsInput string) hcl.Diagnostics {
+	job, err := parseJobHCL(fileName, hclInput, varsInput)
+	if err != nil {
+		return parseDiagnostics(fileName, err)
+	}
+
+	var diags hcl.Diagnostics
+	for _, problem := range lintJob(job) {
+		diags = append(diags, jobDiagnostic(fileName, problem))
+	}
+	return diags
+}
+
+// parseDiagnostics converts an error returned by Nomad's jobspec parser into
+// error diagnostics of the named job file.
+func parseDiagnostics(fileName string, err error) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if errors.As(err, &diags) {
+		return diags
+	}
+	// jobspec2 flattens decoding diagnostics into a single error, one per line.
+	for _, line := range strings.Split(err.Error(), "\n") {
+		diags = append(diags, jobDiagnostic(fileName, line))
+	}
+	return diags
+}
+
+// jobDiagnostic wraps a validation problem of the named job file in an error diagnostic.
+func jobDiagnostic(fileName string, detail string) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("Invalid job %s", fileName),
+		Detail:   detail,
+	}
+}
+
+// stringValue dereferences an optional string from the Nomad API types.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,42 @@
+//go:build js
+
+package converter
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// lintJob checks the structure of a parsed job: an ID, a known type and
+// groups of tasks with a driver. Nomad's server-side job validation is left
+// out of the WASM build for its size.
+func lintJob(job *api.Job) []string {
+	var problems []string
+	if stringValue(job.ID) == "" {
+		problems = append(problems, "Missing job ID")
+	}
+	switch jobType := stringValue(job.Type); jobType {
+	case "", "service", "batch", "system", "sysbatch":
+	default:
+		problems = append(problems, fmt.Sprintf("Invalid job type: %q", jobType))
+	}
+	if len(job.TaskGroups) == 0 {
+		problems = append(problems, "Missing job task groups")
+	}
+	for idx, group := range job.TaskGroups {
+		name := stringValue(group.Name)
+		if name == "" {
+			problems = append(problems, fmt.Sprintf("Job task group %d missing name", idx+1))
+		}
+		if len(group.Tasks) == 0 {
+			problems = append(problems, fmt.Sprintf("Task group %s validation failed: Missing tasks for task group", name))
+		}
+		for _, task := range group.Tasks {
+			if task.Driver == "" {
+				problems = append(problems, fmt.Sprintf("Task group %s validation failed: Task %s validation failed: Missing task driver", name, task.Name))
+			}
+		}
+	}
+	return problems
+}
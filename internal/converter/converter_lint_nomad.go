@@ -0,0 +1,66 @@
+//go:build !js
+
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// nestedErrorCount matches the headers multierror writes before nested errors.
+var nestedErrorCount = regexp.MustCompile(`^\d+ errors? occurred:$`)
+
+// lintJob validates a parsed job with Nomad's server-side job validation. The
+// job is canonicalized like the HTTP API does and carried over to Nomad's
+// internal job type through its JSON encoding, whose field names both types
+// share.
+func lintJob(job *api.Job) []string {
+	job.Canonicalize()
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return []string{fmt.Sprintf("Job cannot be encoded for validation: %v", err)}
+	}
+	var structJob structs.Job
+	if err := json.Unmarshal(payload, &structJob); err != nil {
+		return []string{fmt.Sprintf("Job cannot be decoded for validation: %v", err)}
+	}
+	structJob.Canonicalize()
+
+	err = structJob.Validate()
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if merr, ok := err.(*multierror.Error); ok {
+		errs = merr.Errors
+	}
+	problems := make([]string, 0, len(errs))
+	for _, err := range errs {
+		problems = append(problems, flattenValidationError(err.Error()))
+	}
+	return problems
+}
+
+// flattenValidationError joins the nested error lists Nomad writes for groups
+// and tasks into a single line, e.g. "Task group web validation failed: Task
+// web validation failed: Missing task driver".
+func flattenValidationError(message string) string {
+	var parts []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "* ")
+		if line == "" || nestedErrorCount.MatchString(line) {
+			continue
+		}
+		if i := strings.LastIndex(line, ": "); i >= 0 && nestedErrorCount.MatchString(line[i+2:]) {
+			line = line[:i+1]
+		}
+		parts = append(parts, line)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,225 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+// fixtures lists every compose input used in the tests together with the
+// options it is converted with. All generated jobs must pass Nomad's job validation.
+var fixtures = []struct {
+	name string
+	yaml string
+	opts converter.Options
+}{
+	{"sample", sampleDockerComposeYAML, converter.Options{}},
+	{"split none", splitDockerComposeYAML, converter.Options{}},
+	{"split service", splitDockerComposeYAML, converter.Options{Split: converter.SplitService}},
+	{"split label", splitDockerComposeYAML, converter.Options{Split: converter.SplitLabel}},
+	{"sidecar", sidecarDockerComposeYAML, converter.Options{}},
+	{"resources", resourcesDockerComposeYAML, converter.Options{}},
+	{"variables", resourcesDockerComposeYAML, converter.Options{Variables: allVariables}},
+	{"podman", sampleDockerComposeYAML, converter.Options{Driver: converter.DriverPodman}},
+	{"security", securityDockerComposeYAML, converter.Options{}},
+	{"tuning", tuningDockerComposeYAML, converter.Options{}},
+	{"identity", identityDockerComposeYAML, converter.Options{}},
+	{"shutdown", shutdownDockerComposeYAML, converter.Options{}},
+	{"logging", loggingDockerComposeYAML, converter.Options{}},
+	{"labels", labelsDockerComposeYAML, converter.Options{Provenance: true, SourceName: "docker-compose.yml"}},
+	{"label tags", labelsDockerComposeYAML, converter.Options{DeployLabels: converter.DeployLabelsTags}},
+	{"traefik", traefikDockerComposeYAML, converter.Options{}},
+	{"networks", networksDockerComposeYAML, converter.Options{}},
+	{"cni networks", networksDockerComposeYAML, converter.Options{CNINetworks: map[string]string{"data": "isolated", "lan": "lan-macvlan"}}},
+	{"network mode", networkModeDockerComposeYAML, converter.Options{}},
+	{"connect", connectDockerComposeYAML, converter.Options{Connect: true}},
+	{"nomad discovery", discoveryDockerComposeYAML, converter.Options{Discovery: converter.DiscoveryNomad}},
+	{"dns discovery", discoveryDockerComposeYAML, converter.Options{Discovery: converter.DiscoveryDNS}},
+	{"deploy mode", deployModeDockerComposeYAML, converter.Options{}},
+	{"placement", placementDockerComposeYAML, converter.Options{}},
+	{"update", updateDockerComposeYAML, converter.Options{}},
+	{"restart policy", restartDockerComposeYAML, converter.Options{}},
+	{"one-shot", oneShotDockerComposeYAML, converter.Options{}},
+	{"periodic", periodicDockerComposeYAML, converter.Options{}},
+}
+
+func TestConvertToNomadJobs_FixturesLint(t *testing.T) {
+	for _, fixture := range fixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			files, err := converter.ConvertToNomadJobs(fixture.yaml, fixture.opts)
+			if err != nil {
+				t.Fatalf("ConvertToNomadJobs failed: %v", err)
+			}
+			for fileName, hclOutput := range files {
+				if strings.HasSuffix(fileName, ".vars.hcl") {
+					continue
+				}
+				varsOutput := files[strings.TrimSuffix(fileName, ".nomad.hcl")+".vars.hcl"]
+				if diags := converter.LintNomadHCLWithVars(fileName, hclOutput, varsOutput); diags.HasErrors() {
+					t.Errorf("Generated job %s fails lint: %v\n%s", fileName, diags, hclOutput)
+				}
+			}
+		})
+	}
+}
+
+func TestLintNomadHCL_Problems(t *testing.T) {
+	tests := []struct {
+		name     string
+		hcl      string
+		expected string
+	}{
+		{
+			name:     "syntax error",
+			hcl:      "job \"web\" {\n  group \"web\" {\n",
+			expected: "Unclosed configuration block",
+		},
+		{
+			name: "duplicate groups",
+			hcl: `job "web" {
+  group "web" {
+    task "web" {
+      driver = "docker"
+      config { image = "nginx" }
+    }
+  }
+  group "web" {
+    task "web" {
+      driver = "docker"
+      config { image = "nginx" }
+    }
+  }
+}`,
+			expected: "redefines 'web'",
+		},
+		{
+			name: "missing driver",
+			hcl: `job "web" {
+  group "web" {
+    task "web" {
+      config { image = "nginx" }
+    }
+  }
+}`,
+			expected: "Task web validation failed: Missing task driver",
+		},
+		{
+			name: "undefined volume",
+			hcl: `job "web" {
+  group "web" {
+    task "web" {
+      driver = "docker"
+      config { image = "nginx" }
+      volume_mount {
+        volume      = "logs"
+        destination = "/var/log"
+      }
+    }
+  }
+}`,
+			expected: "references undefined volume logs",
+		},
+		{
+			name: "unknown port label",
+			hcl: `job "web" {
+  group "web" {
+    task "web" {
+      driver = "docker"
+      config {
+        image = "nginx"
+        ports = ["http"]
+      }
+      service {
+        name = "web"
+        port = "http"
+      }
+    }
+  }
+}`,
+			expected: `port label "http" referenced by services web does not exist`,
+		},
+		{
+			name: "update deadlines",
+			hcl: `job "web" {
+  group "web" {
+    update {
+      min_healthy_time = "5m"
+    }
+    task "web" {
+      driver = "docker"
+      config { image = "nginx" }
+    }
+  }
+}`,
+			expected: "Minimum healthy time must be less than healthy deadline",
+		},
+		{
+			name: "ambiguous restart",
+			hcl: `job "web" {
+  group "web" {
+    task "web" {
+      driver = "docker"
+      config { image = "nginx" }
+    }
+    restart {
+      attempts = 0
+      mode     = "delay"
+    }
+  }
+}`,
+			expected: `Restart policy "delay" with 0 attempts is ambiguous`,
+		},
+		{
+			name: "invalid cron",
+			hcl: `job "backup" {
+  type = "batch"
+  periodic {
+    crons = ["0 0 31 2 * * * *"]
+  }
+  group "backup" {
+    task "backup" {
+      driver = "docker"
+      config { image = "postgres" }
+    }
+  }
+}`,
+			expected: `Invalid cron spec "0 0 31 2 * * * *"`,
+		},
+		{
+			name: "periodic service job",
+			hcl: `job "backup" {
+  periodic {
+    crons = ["@daily"]
+  }
+  group "backup" {
+    task "backup" {
+      driver = "docker"
+      config { image = "postgres" }
+    }
+  }
+}`,
+			expected: `Periodic can only be used with "batch" or "sysbatch" scheduler`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := converter.LintNomadHCL("test.nomad.hcl", tt.hcl)
+			if !diags.HasErrors() {
+				t.Fatalf("Expected lint errors, but got none")
+			}
+			if !strings.Contains(diags.Error(), tt.expected) {
+				t.Errorf("Expected diagnostics to contain %q, got: %v", tt.expected, diags)
+			}
+		})
+	}
+}
+
+func TestConvertToNomadJobs_Lint(t *testing.T) {
+	_, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{Lint: true})
+	if err != nil {
+		t.Errorf("Expected sample to convert with lint enabled, but got: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// defaultEphemeralDiskMB is the ephemeral disk Nomad allocates to a group by
+// default, which has to hold the rotated logs of all its tasks.
+const defaultEphemeralDiskMB = 300
+
+// taskLogging holds the logging configuration of a compose service.
+type taskLogging struct {
+	driver  string
+	options map[string]string
+}
+
+// serviceLogging returns the logging configuration of a compose service.
+func serviceLogging(service dockercompose.Service) (taskLogging, bool) {
+	if service.Logging == nil || (service.Logging.Driver == "" && len(service.Logging.Options) == 0) {
+		return taskLogging{}, false
+	}
+	logging := taskLogging{driver: service.Logging.Driver, options: make(map[string]string)}
+	for key, value := range service.Logging.Options {
+		logging.options[key] = scalarString(value)
+	}
+	return logging, true
+}
+
+// isJSONFile reports whether the logs are written by docker's json-file
+// driver, the default when no driver is named.
+func (l taskLogging) isJSONFile() bool {
+	return l.driver == "" || l.driver == "json-file"
+}
+
+// sortedOptionKeys returns the option names in sorted order.
+func (l taskLogging) sortedOptionKeys() []string {
+	keys := make([]string, 0, len(l.options))
+	for key := range l.options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// logRotation holds the json-file rotation of a service's logs in Nomad's
+// terms, with notes on the options that could not be read.
+type logRotation struct {
+	maxFiles    int64 // Zero keeps Nomad's default
+	maxFileSize int64 // In MB, zero keeps Nomad's default
+	notes       []string
+}
+
+// serviceLogRotation returns the log rotation of a service using docker's
+// json-file driver.
+func serviceLogRotation(service dockercompose.Service) (logRotation, bool) {
+	logging, ok := serviceLogging(service)
+	if !ok || !logging.isJSONFile() {
+		return logRotation{}, false
+	}
+	var rotation logRotation
+	if raw, ok := logging.options["max-file"]; ok {
+		files, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || files < 1 {
+			rotation.notes = append(rotation.notes, fmt.Sprintf("Skipping invalid logging max-file '%s'.", raw))
+		} else {
+			rotation.maxFiles = files
+		}
+	}
+	if raw, ok := logging.options["max-size"]; ok {
+		bytes, err := parseByteSize(raw)
+		if err != nil {
+			rotation.notes = append(rotation.notes, fmt.Sprintf("Skipping logging max-size: %v.", err))
+		} else {
+			// Nomad rotates logs in whole megabytes.
+			rotation.maxFileSize = max((bytes+(1<<20-1))>>20, 1)
+		}
+	}
+	if rotation.maxFiles == 0 && rotation.maxFileSize == 0 && len(rotation.notes) == 0 {
+		return logRotation{}, false
+	}
+	return rotation, true
+}
+
+// usageMB returns the disk space the rotated logs may use. Nomad defaults to
+// 10 files of 10 MB each.
+func (l logRotation) usageMB() int64 {
+	files, size := int64(10), int64(10)
+	if l.maxFiles > 0 {
+		files = l.maxFiles
+	}
+	if l.maxFileSize > 0 {
+		size = l.maxFileSize
+	}
+	return files * size
+}
+
+// addLogging writes the logging driver of a service to the task config, and
+// maps json-file rotation options to the task's `logs` block.
+func (r *jobRenderer) addLogging(taskBody *hclwrite.Body, configBody *hclwrite.Body, service dockercompose.Service) {
+	logging, ok := serviceLogging(service)
+	if !ok {
+		return
+	}
+	r.driver.setLogging(configBody, logging)
+	rotation, ok := serviceLogRotation(service)
+	if !ok {
+		return
+	}
+
+	logsBody := taskBody.AppendNewBlock("logs", nil).Body()
+	for _, note := range rotation.notes {
+		logsBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	if rotation.maxFiles > 0 {
+		logsBody.SetAttributeValue("max_files", cty.NumberIntVal(rotation.maxFiles))
+	}
+	if rotation.maxFileSize > 0 {
+		logsBody.SetAttributeValue("max_file_size", cty.NumberIntVal(rotation.maxFileSize))
+	}
+	taskBody.AppendNewline()
+}
+
+// addEphemeralDisk raises the group's ephemeral_disk when the logs of one of
+// its tasks would not fit the default, which Nomad rejects. The disk keeps the
+// default space next to the largest logs.
+func (r *jobRenderer) addEphemeralDisk(groupBody *hclwrite.Body, group *groupPlan) {
+	var largest string
+	var usage int64
+	for _, taskName := range append(append([]string{}, group.prestart...), group.members()...) {
+		if rotation, ok := serviceLogRotation(r.dc.Services[taskName]); ok && rotation.usageMB() > usage {
+			largest, usage = taskName, rotation.usageMB()
+		}
+	}
+	if usage < defaultEphemeralDiskMB {
+		return
+	}
+	groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Logs of task '%s' may use %d MB, more than the default ephemeral_disk of %d MB holds.", largest, usage, defaultEphemeralDiskMB)))
+	diskBody := groupBody.AppendNewBlock("ephemeral_disk", nil).Body()
+	diskBody.SetAttributeValue("size", cty.NumberIntVal(usage+defaultEphemeralDiskMB))
+	groupBody.AppendNewline()
+}
@@ -0,0 +1,88 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const loggingDockerComposeYAML = `
+services:
+  web:
+    image: nginx
+    logging:
+      driver: json-file
+      options:
+        max-size: 50m
+        max-file: 3
+  api:
+    image: api
+    logging:
+      driver: syslog
+      options:
+        syslog-address: "tcp://192.168.0.42:123"
+        tag: api
+  archive:
+    image: archive
+    logging:
+      options:
+        max-size: 1g
+`
+
+func TestConvertToNomadHCL_Logging(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(loggingDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"json-file driver": `task "web"[\s\S]*?logging\s*\{\s*type\s*=\s*"json-file"\s*config\s*\{\s*max-file\s*=\s*"3"\s*max-size\s*=\s*"50m"\s*\}`,
+		"json-file logs":   `task "web"[\s\S]*?logs\s*\{\s*max_files\s*=\s*3\s*max_file_size\s*=\s*50\s*\}`,
+		"syslog driver":    `logging\s*\{\s*type\s*=\s*"syslog"\s*config\s*\{\s*syslog-address\s*=\s*"tcp://192\.168\.0\.42:123"\s*tag\s*=\s*"api"\s*\}`,
+		"default driver":   `task "archive"[\s\S]*?type\s*=\s*"json-file"`,
+		"size in MB":       `max_file_size\s*=\s*1024`,
+		"disk note":        `group "archive"[\s\S]*?# Logs of task 'archive' may use 10240 MB, more than the default ephemeral_disk of 300 MB holds\.\s*ephemeral_disk\s*\{\s*size\s*=\s*10540\s*\}`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Count(hclOutput, "ephemeral_disk {") != 1 {
+		t.Errorf("Logs within the default ephemeral_disk should keep it:\n%s", hclOutput)
+	}
+	if regexp.MustCompile(`task "api"[\s\S]*?logs\s*\{[\s\S]*?group "archive"`).MatchString(hclOutput) {
+		t.Errorf("Only json-file options should map to the logs block:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_PodmanLogging(t *testing.T) {
+	yaml := `
+services:
+  web:
+    image: nginx
+    logging:
+      driver: journald
+      options:
+        tag: web
+  api:
+    image: api
+    logging:
+      driver: syslog
+`
+	files, err := converter.ConvertToNomadJobs(yaml, converter.Options{Driver: converter.DriverPodman})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+	if !regexp.MustCompile(`logging\s*\{\s*driver\s*=\s*"journald"\s*options\s*=\s*\{\s*tag\s*=\s*"web"\s*\}`).MatchString(hclOutput) {
+		t.Errorf("HCL output does not map journald logging for podman:\n%s", hclOutput)
+	}
+	if !strings.Contains(hclOutput, "# The podman driver does not support the syslog logging driver") {
+		t.Errorf("HCL output does not note the unsupported logging driver:\n%s", hclOutput)
+	}
+}
@@ -0,0 +1,95 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// Version is the converter version recorded in provenance meta. Builds set it
+// with -ldflags "-X github.com/justmiles/docker-compose-to-nomad/internal/converter.Version=<version>".
+var Version = "dev"
+
+// DeployLabelTarget selects where compose `deploy.labels` are written.
+type DeployLabelTarget string
+
+const (
+	// DeployLabelsMeta writes deploy labels to the group `meta`.
+	DeployLabelsMeta DeployLabelTarget = ""
+	// DeployLabelsTags writes deploy labels as "key=value" tags of the group's
+	// service. Groups without a service fall back to `meta`.
+	DeployLabelsTags DeployLabelTarget = "tags"
+)
+
+// Provenance meta keys recorded on the job.
+const (
+	metaConverterVersion = "compose2nomad_version"
+	metaSourceFile       = "compose2nomad_source"
+	metaSourceHash       = "compose2nomad_sha256"
+)
+
+// inputHash returns the hex encoded SHA-256 of the compose input.
+func inputHash(yamlInput string) string {
+	sum := sha256.Sum256([]byte(yamlInput))
+	return hex.EncodeToString(sum[:])
+}
+
+// containerLabels returns the compose labels of a service passed on to the
+// container. The label selecting the job of the service, the Traefik labels,
+// which become service tags, and the ofelia labels, which become periodic
+// jobs, are left out.
+func containerLabels(service dockercompose.Service) map[string]string {
+	labels := parseKeyValues(service.Labels)
+	delete(labels, jobLabel)
+	for key := range labels {
+		if isTraefikLabel(key) || isOfeliaLabel(key) {
+			delete(labels, key)
+		}
+	}
+	return labels
+}
+
+// groupDeployLabels merges the deploy labels of the group members, leaving out
+// the Traefik labels. The labels of the owning service win.
+func (r *jobRenderer) groupDeployLabels(group *groupPlan) map[string]string {
+	labels := make(map[string]string)
+	members := group.members()
+	for i := len(members) - 1; i >= 0; i-- {
+		if deploy := r.dc.Services[members[i]].Deploy; deploy != nil {
+			for key, value := range parseKeyValues(deploy.Labels) {
+				if !isTraefikLabel(key) {
+					labels[key] = value
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// deployLabelTags returns deploy labels as sorted "key=value" service tags.
+func deployLabelTags(labels map[string]string) []string {
+	tags := make([]string, 0, len(labels))
+	for _, key := range sortedKeys(labels) {
+		tags = append(tags, key+"="+labels[key])
+	}
+	return tags
+}
+
+// addJobMeta writes the provenance meta of the job: the converter version,
+// the compose file name when known, and the hash of the compose input.
+func (r *jobRenderer) addJobMeta(jobBody *hclwrite.Body) {
+	if !r.opts.Provenance {
+		return
+	}
+	meta := map[string]string{
+		metaConverterVersion: Version,
+		metaSourceHash:       r.sourceHash,
+	}
+	if r.opts.SourceName != "" {
+		meta[metaSourceFile] = r.opts.SourceName
+	}
+	jobBody.SetAttributeValue("meta", stringMapVal(meta))
+}
@@ -0,0 +1,101 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const labelsDockerComposeYAML = `
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+    labels:
+      com.example.team: web
+      x-nomad-job: front
+    deploy:
+      labels:
+        owner: web-team
+        tier: frontend
+  worker:
+    image: worker
+    labels:
+      - logging=fluent
+      - debug
+    deploy:
+      labels:
+        - owner=ops
+`
+
+func TestConvertToNomadHCL_Labels(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(labelsDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"map labels":  `labels\s*=\s*\{\s*"com\.example\.team"\s*=\s*"web"\s*\}`,
+		"list labels": `labels\s*=\s*\{\s*debug\s*=\s*""\s*logging\s*=\s*"fluent"\s*\}`,
+		"web meta":    `group "web"\s*\{\s*count\s*=\s*1\s*meta\s*=\s*\{\s*owner\s*=\s*"web-team"\s*tier\s*=\s*"frontend"\s*\}`,
+		"worker meta": `group "worker"\s*\{\s*count\s*=\s*1\s*meta\s*=\s*\{\s*owner\s*=\s*"ops"\s*\}`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Contains(hclOutput, `"x-nomad-job"`) {
+		t.Errorf("The job selection label should not be passed to the container:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_DeployLabelTags(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(labelsDockerComposeYAML, converter.Options{DeployLabels: converter.DeployLabelsTags})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	if !regexp.MustCompile(`service\s*\{[^}]*tags\s*=\s*\["owner=web-team", "tier=frontend"\]`).MatchString(hclOutput) {
+		t.Errorf("Deploy labels are not written as service tags:\n%s", hclOutput)
+	}
+	if !regexp.MustCompile(`# Group 'worker' registers no service[^\n]*\n\s*meta\s*=\s*\{\s*owner\s*=\s*"ops"`).MatchString(hclOutput) {
+		t.Errorf("Deploy labels of a group without service should fall back to meta:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_Provenance(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(labelsDockerComposeYAML, converter.Options{Provenance: true, SourceName: "docker-compose.yml"})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	sum := sha256.Sum256([]byte(labelsDockerComposeYAML))
+	checks := map[string]string{
+		"hash":    `compose2nomad_sha256\s*=\s*"` + hex.EncodeToString(sum[:]) + `"`,
+		"source":  `compose2nomad_source\s*=\s*"docker-compose\.yml"`,
+		"version": `compose2nomad_version\s*=\s*"` + regexp.QuoteMeta(converter.Version) + `"`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(`job "my-docker-compose-job"\s*\{[^{]*meta\s*=\s*\{[^}]*` + pattern).MatchString(hclOutput) {
+			t.Errorf("Job meta does not record the %s:\n%s", name, hclOutput)
+		}
+	}
+
+	files, err = converter.ConvertToNomadJobs(labelsDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if strings.Contains(files["my-docker-compose-job.nomad.hcl"], "compose2nomad_") {
+		t.Errorf("Provenance meta should only be written when requested")
+	}
+}
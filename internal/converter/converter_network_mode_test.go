@@ -0,0 +1,71 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const networkModeDockerComposeYAML = `
+services:
+  exporter:
+    image: node-exporter
+    network_mode: host
+    hostname: exporter
+    ports:
+      - "9100"
+      - "8080:80"
+  exporter-proxy:
+    image: proxy
+    network_mode: service:exporter
+  offline:
+    image: batch
+    network_mode: none
+    ports:
+      - "5000:5000"
+  legacy:
+    image: legacy
+    network_mode: bridge
+    ports:
+      - "8081:81"
+`
+
+func TestConvertToNomadHCL_NetworkMode(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(networkModeDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"host network":       `network\s*\{\s*mode\s*=\s*"host"`,
+		"host static port":   `port "port_9100"\s*\{\s*static\s*=\s*9100\s*\}`,
+		"host mapped port":   `port "http"\s*\{\s*static\s*=\s*80\s*\}`,
+		"mapping warning":    `# Port mapping 8080:80 has no effect with host networking; the service listens on host port 80\.`,
+		"host driver mode":   `task "exporter"[\s\S]*?network_mode\s*=\s*"host"`,
+		"sidecar host mode":  `task "exporter-proxy"[\s\S]*?network_mode\s*=\s*"host"`,
+		"hostname dropped":   `# Host networking uses the host's hostname; the service's settings are dropped\.`,
+		"none network":       `network\s*\{\s*mode\s*=\s*"none"\s*\}`,
+		"none driver mode":   `task "offline"[\s\S]*?network_mode\s*=\s*"none"`,
+		"none ports dropped": `# Service 'offline' has no network; its ports 5000:5000 are dropped\.`,
+		"bridge default":     `port "port_81"\s*\{\s*static\s*=\s*8081\s*to\s*=\s*81\s*\}`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+
+	exporter := hclOutput[strings.Index(hclOutput, `group "exporter"`):strings.Index(hclOutput, `group "legacy"`)]
+	for _, unexpected := range []string{"to ", "ports ", "hostname "} {
+		if strings.Contains(exporter, unexpected) {
+			t.Errorf("Host networking should not map %q:\n%s", unexpected, exporter)
+		}
+	}
+	if strings.Contains(hclOutput, `network_mode = "bridge"`) {
+		t.Errorf("network_mode bridge is the driver default and should not be written:\n%s", hclOutput)
+	}
+}
@@ -0,0 +1,173 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// defaultNetwork is the network compose attaches services to when they do not
+// declare any.
+const defaultNetwork = "default"
+
+// Compose network modes mapped to the group network mode. Containers in them
+// use the network namespace of the host, or none, instead of a bridge.
+const (
+	networkModeHost = "host"
+	networkModeNone = "none"
+)
+
+// driverNetworkMode returns the network mode a service's container runs in,
+// or an empty mode for the default bridge network. An explicit
+// `network_mode: bridge` is the drivers' default.
+func driverNetworkMode(service dockercompose.Service) string {
+	switch service.NetworkMode {
+	case networkModeHost, networkModeNone:
+		return service.NetworkMode
+	}
+	return ""
+}
+
+// serviceNamePattern matches the names Nomad accepts for services.
+var serviceNamePattern = regexp.MustCompile(`^(?i:[a-z0-9]|[a-z0-9][a-z0-9\-]{0,61}[a-z0-9])$`)
+
+// serviceNetwork is a compose network a service is attached to.
+type serviceNetwork struct {
+	name        string
+	aliases     []string
+	ipv4Address string
+	ipv6Address string
+}
+
+// serviceNetworks returns the networks of a compose service, given as a list
+// of names or as a map of name to aliases and addresses.
+func serviceNetworks(service dockercompose.Service) []serviceNetwork {
+	var networks []serviceNetwork
+	switch typed := service.Networks.(type) {
+	case []any:
+		for _, item := range typed {
+			if name, ok := item.(string); ok {
+				networks = append(networks, serviceNetwork{name: name})
+			}
+		}
+	case map[string]any:
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			network := serviceNetwork{name: name}
+			if settings, ok := typed[name].(map[string]any); ok {
+				network.aliases = stringList(settings["aliases"], false)
+				network.ipv4Address, _ = settings["ipv4_address"].(string)
+				network.ipv6Address, _ = settings["ipv6_address"].(string)
+			}
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// groupNetworking is the group network mode and the service aliases derived
+// from the compose networks of a group.
+type groupNetworking struct {
+	mode    string // empty when the service declares no networks
+	aliases []string
+	notes   []string
+}
+
+// groupNetworking maps the compose networks of the owning service to the
+// network mode of its group. A group joins a single network: the CNI network
+// mapped to the first of them, or a bridge network when none is mapped.
+// Topology that cannot be reproduced is noted.
+func (r *jobRenderer) groupNetworking(group *groupPlan) groupNetworking {
+	var networking groupNetworking
+	for _, sidecarName := range group.sidecars {
+		if r.dc.Services[sidecarName].Networks != nil {
+			networking.notes = append(networking.notes, fmt.Sprintf("Service '%s' shares the network namespace of '%s'; its networks are ignored.", sidecarName, group.name))
+		}
+	}
+
+	networks := serviceNetworks(r.dc.Services[group.name])
+	if len(networks) == 0 {
+		return networking
+	}
+
+	var cniNetwork, cniSource string
+	var unmapped []string
+	seenAliases := map[string]bool{group.name: true}
+	for _, network := range networks {
+		definition, declared := r.dc.Networks[network.name]
+		if !declared && network.name != defaultNetwork {
+			networking.notes = append(networking.notes, fmt.Sprintf("Network '%s' is not declared in the top-level networks.", network.name))
+		}
+
+		for _, address := range []string{network.ipv4Address, network.ipv6Address} {
+			if address != "" {
+				networking.notes = append(networking.notes, fmt.Sprintf("Static address %s on network '%s' cannot be reproduced; Nomad allocates the group address.", address, network.name))
+			}
+		}
+
+		for _, alias := range network.aliases {
+			switch {
+			case seenAliases[alias]:
+			case !serviceNamePattern.MatchString(alias):
+				networking.notes = append(networking.notes, fmt.Sprintf("Alias '%s' on network '%s' is not a valid Nomad service name and is not registered.", alias, network.name))
+			default:
+				networking.aliases = append(networking.aliases, alias)
+			}
+			seenAliases[alias] = true
+		}
+
+		cni, mapped := r.opts.CNINetworks[network.name]
+		switch {
+		case mapped && cniNetwork == "":
+			cniNetwork, cniSource = cni, network.name
+			continue
+		case mapped:
+			if cni != cniNetwork {
+				networking.notes = append(networking.notes, fmt.Sprintf("Nomad groups join a single network; network '%s' (CNI network '%s') is not joined, only '%s' of network '%s'.", network.name, cni, cniNetwork, cniSource))
+			}
+			continue
+		}
+		unmapped = append(unmapped, network.name)
+
+		switch {
+		case definition.Driver == "macvlan" || definition.Driver == "ipvlan":
+			networking.notes = append(networking.notes, fmt.Sprintf("Network '%s' uses the %s driver, which a bridge network cannot reproduce; map it to a CNI network.", network.name, definition.Driver))
+		case definition.Driver == "overlay":
+			networking.notes = append(networking.notes, fmt.Sprintf("Network '%s' spans hosts, but bridge networks are host-local; services on other hosts are reached through their published ports.", network.name))
+		}
+		if definition.Internal {
+			networking.notes = append(networking.notes, fmt.Sprintf("Network '%s' is internal, but a bridge network allows outbound traffic; map it to a CNI network without egress to isolate it.", network.name))
+		}
+		if isExternal(definition.External) {
+			networking.notes = append(networking.notes, fmt.Sprintf("Network '%s' is external; map it to a CNI network to join it.", network.name))
+		}
+	}
+
+	if cniNetwork == "" {
+		networking.mode = "bridge"
+		return networking
+	}
+	networking.mode = "cni/" + cniNetwork
+	for _, name := range unmapped {
+		networking.notes = append(networking.notes, fmt.Sprintf("Network '%s' has no CNI mapping and is not joined; the group joins CNI network '%s'.", name, cniNetwork))
+	}
+	return networking
+}
+
+// isExternal reports whether a compose network is external, given as a bool
+// or, in older files, as a map naming the network.
+func isExternal(external any) bool {
+	switch typed := external.(type) {
+	case bool:
+		return typed
+	case map[string]any:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const networksDockerComposeYAML = `
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+    networks:
+      - frontend
+  api:
+    image: api
+    ports:
+      - "3000"
+    networks:
+      frontend:
+        aliases:
+          - backend
+          - api.internal
+      data:
+        ipv4_address: 172.20.0.10
+  db:
+    image: postgres
+    networks:
+      data:
+        aliases:
+          - database
+  cache:
+    image: redis
+    networks:
+      - lan
+      - missing
+  worker:
+    image: worker
+networks:
+  frontend: {}
+  data:
+    internal: true
+  lan:
+    driver: macvlan
+`
+
+func TestConvertToNomadHCL_Networks(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(networksDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"web bridge":       `group "web"[\s\S]*?network\s*\{\s*mode\s*=\s*"bridge"\s*port "http"`,
+		"db bridge":        `network\s*\{\s*mode\s*=\s*"bridge"\s*\}`,
+		"alias service":    `service\s*\{\s*name\s*=\s*"backend"\s*port\s*=\s*"port_3000"\s*provider\s*=\s*"nomad"\s*\}`,
+		"invalid alias":    `# Alias 'api\.internal' on network 'frontend' is not a valid Nomad service name`,
+		"static address":   `# Static address 172\.20\.0\.10 on network 'data' cannot be reproduced`,
+		"internal":         `# Network 'data' is internal`,
+		"macvlan":          `# Network 'lan' uses the macvlan driver`,
+		"undeclared":       `# Network 'missing' is not declared`,
+		"unregistered":     `# Group 'db' publishes no port to register a service on, so its network aliases are not registered\.`,
+		"worker untouched": `group "worker"\s*\{\s*count\s*=\s*1\s*task "worker"`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_CNINetworks(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(networksDockerComposeYAML, converter.Options{
+		CNINetworks: map[string]string{"data": "isolated", "lan": "lan-macvlan"},
+	})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	checks := map[string]string{
+		"api cni":    `group "api"[\s\S]*?mode\s*=\s*"cni/isolated"`,
+		"cache cni":  `group "cache"[\s\S]*?mode\s*=\s*"cni/lan-macvlan"`,
+		"unmapped":   `# Network 'frontend' has no CNI mapping and is not joined; the group joins CNI network 'isolated'\.`,
+		"web bridge": `group "web"[\s\S]*?mode\s*=\s*"bridge"`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	for _, note := range []string{"Network 'data' is internal", "macvlan driver"} {
+		if strings.Contains(hclOutput, note) {
+			t.Errorf("Networks mapped to CNI should not be noted as %q:\n%s", note, hclOutput)
+		}
+	}
+}
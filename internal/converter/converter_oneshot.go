@@ -0,0 +1,139 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// oneShotLabel is the service extension key (or label) marking a service as
+// running to completion, or as long-running, overriding the detection.
+const oneShotLabel = "x-nomad-oneshot"
+
+// dependsOnCompleted is the depends_on condition waiting for a service to
+// exit successfully.
+const dependsOnCompleted = "service_completed_successfully"
+
+// oneShotReason returns why a service is considered to run to completion, or
+// an empty string when it is long-running. The `x-nomad-oneshot` extension
+// key or label decides when set; otherwise services other services wait on
+// with `condition: service_completed_successfully` are one-shot, as are
+// services without published ports that are never restarted, such as
+// migrations and seeders.
+func oneShotReason(dc *dockercompose.DockerCompose, serviceName string) string {
+	service := dc.Services[serviceName]
+	if service.XNomadOneshot != nil {
+		if *service.XNomadOneshot {
+			return oneShotLabel
+		}
+		return ""
+	}
+	if value, ok := parseKeyValues(service.Labels)[oneShotLabel]; ok {
+		if oneShot, err := strconv.ParseBool(value); err == nil {
+			if oneShot {
+				return oneShotLabel + " label"
+			}
+			return ""
+		}
+	}
+
+	dependents := make([]string, 0, len(dc.Services))
+	for dependentName := range dc.Services {
+		dependents = append(dependents, dependentName)
+	}
+	sort.Strings(dependents)
+	for _, dependentName := range dependents {
+		if dependsOnCondition(dc.Services[dependentName].DependsOn, serviceName) == dependsOnCompleted {
+			return fmt.Sprintf("'%s' waits for it to complete", dependentName)
+		}
+	}
+
+	if len(service.Ports) > 0 {
+		return ""
+	}
+	if service.Deploy != nil && service.Deploy.RestartPolicy != nil {
+		if service.Deploy.RestartPolicy.Condition == "none" {
+			return "restart_policy condition none"
+		}
+		return ""
+	}
+	if service.Restart == "no" {
+		return `restart "no"`
+	}
+	return ""
+}
+
+// dependsOnCondition returns the condition a `depends_on` section waits for
+// the named service with, or an empty string when it does not name it or
+// lists names only.
+func dependsOnCondition(raw any, serviceName string) string {
+	dependencies, ok := raw.(map[string]any)
+	if !ok {
+		return ""
+	}
+	settings, _ := dependencies[serviceName].(map[string]any)
+	condition, _ := settings["condition"].(string)
+	return condition
+}
+
+// planPrestart folds one-shot groups that exactly one other group depends on
+// into that group as prestart tasks, which run before its tasks on every
+// allocation. Nomad starts prestart tasks together, so one-shot services
+// depending on each other, and those folding their own sidecars, stay in
+// groups of their own. The remaining groups are returned.
+func planPrestart(dc *dockercompose.DockerCompose, groups []*groupPlan) []*groupPlan {
+	ownerOf := make(map[string]*groupPlan)
+	for _, group := range groups {
+		for _, memberName := range group.members() {
+			ownerOf[memberName] = group
+		}
+	}
+	oneShot := make(map[string]bool)
+	for _, group := range groups {
+		if len(group.sidecars) == 0 && deployModeJobTypes[deployMode(dc.Services[group.name])] == jobTypeService && oneShotReason(dc, group.name) != "" {
+			oneShot[group.name] = true
+		}
+	}
+
+	folded := make(map[string]bool)
+	for _, group := range groups {
+		if !oneShot[group.name] {
+			continue
+		}
+		dependents := make(map[*groupPlan]bool)
+		for serviceName, service := range dc.Services {
+			if serviceName == group.name {
+				continue
+			}
+			for _, dependency := range dependsOnNames(service.DependsOn) {
+				if dependency == group.name {
+					dependents[ownerOf[serviceName]] = true
+				}
+			}
+		}
+		if len(dependents) != 1 {
+			continue
+		}
+		for dependent := range dependents {
+			if oneShot[dependent.name] {
+				continue
+			}
+			dependent.prestart = append(dependent.prestart, group.name)
+			if deploy := dc.Services[dependent.name].Deploy; deploy != nil && deploy.Replicas != nil && *deploy.Replicas > 1 {
+				dependent.notes = append(dependent.notes, fmt.Sprintf("Service '%s' runs to completion before the tasks of every allocation of '%s'; it must be safe to run %d times.", group.name, dependent.name, *deploy.Replicas))
+			}
+			folded[group.name] = true
+		}
+	}
+
+	remaining := make([]*groupPlan, 0, len(groups))
+	for _, group := range groups {
+		if !folded[group.name] {
+			sort.Strings(group.prestart)
+			remaining = append(remaining, group)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,119 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const oneShotDockerComposeYAML = `
+name: app
+services:
+  web:
+    image: web
+    ports:
+      - "80:80"
+    deploy:
+      replicas: 2
+    depends_on:
+      migrate:
+        condition: service_completed_successfully
+      db:
+        condition: service_started
+  migrate:
+    image: app
+    command: ["migrate", "up"]
+    depends_on: [db]
+  db:
+    image: postgres
+  seed:
+    image: app
+    restart: "no"
+    depends_on: [db]
+  report:
+    image: report
+    x-nomad-oneshot: true
+  worker:
+    image: worker
+    restart: "no"
+    labels:
+      x-nomad-oneshot: "false"
+`
+
+func TestConvertToNomadJobs_OneShot(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(oneShotDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if _, ok := files["app-batch.nomad.hcl"]; !ok || len(files) != 2 {
+		t.Fatalf("Expected a service and a batch job, got %v", fileNames(files))
+	}
+	service, batch := files["app.nomad.hcl"], files["app-batch.nomad.hcl"]
+
+	checks := map[string]map[string]string{
+		"service job": {
+			"prestart task":  `group "web"[\s\S]*?task "migrate"\s*\{\s*driver\s*=\s*"docker"\s*lifecycle\s*\{\s*hook\s*=\s*"prestart"\s*\}`,
+			"replicas note":  `# Service 'migrate' runs to completion before the tasks of every allocation of 'web'; it must be safe to run 2 times\.`,
+			"long-running":   `group "worker"`,
+			"database group": `group "db"`,
+		},
+		"batch job": {
+			"batch type":   `type\s*=\s*"batch"`,
+			"seed group":   `group "seed"\s*\{\s*count\s*=\s*1\s*# Service 'seed' runs to completion \(restart "no"\), so it runs in a batch job\.`,
+			"report group": `# Service 'report' runs to completion \(x-nomad-oneshot\), so it runs in a batch job\.`,
+		},
+	}
+	for job, patterns := range checks {
+		hcl := service
+		if job == "batch job" {
+			hcl = batch
+		}
+		for name, pattern := range patterns {
+			if !regexp.MustCompile(pattern).MatchString(hcl) {
+				t.Errorf("%s does not contain %s:\n%s", job, name, hcl)
+			}
+		}
+	}
+	if strings.Contains(service, `group "migrate"`) || strings.Contains(batch, "migrate") {
+		t.Errorf("migrate should only run as a prestart task of web:\n%s\n%s", service, batch)
+	}
+}
+
+func TestConvertToNomadJobs_OneShotJobFiles(t *testing.T) {
+	yaml := `
+services:
+  web:
+    image: web
+    ports:
+      - "80:80"
+  migrate:
+    image: app
+    restart: "no"
+`
+	// The browser UI converts through ConvertToNomadJobs and shows every file.
+	files, err := converter.ConvertToNomadJobs(yaml, converter.Options{Lint: true})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	expected := map[string]string{
+		"my-docker-compose-job.nomad.hcl":       "service",
+		"my-docker-compose-job-batch.nomad.hcl": "batch",
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected a service and a batch job file, got %v", fileNames(files))
+	}
+	for fileName, jobType := range expected {
+		hcl := files[fileName]
+		if strings.Count(hcl, `job "`) != 1 || !regexp.MustCompile(`type\s*=\s*"`+jobType+`"`).MatchString(hcl) {
+			t.Errorf("Expected %s to hold a single %s job:\n%s", fileName, jobType, hcl)
+		}
+	}
+
+	if _, err := converter.ConvertToNomadHCL(yaml); err == nil || !strings.Contains(err.Error(), "ConvertToNomadJobs") {
+		t.Errorf("Expected ConvertToNomadHCL to point to ConvertToNomadJobs, got %v", err)
+	}
+}
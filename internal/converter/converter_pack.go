@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// packVariableRoot is the placeholder root of variable references in pack
+// templates. References are rewritten to pack template calls after formatting.
+const packVariableRoot = "__pack__"
+
+// packVersion is the version given to generated packs.
+const packVersion = "0.1.0"
+
+var packVariableReferenceRegex = regexp.MustCompile(packVariableRoot + `\.([a-z0-9_]+)`)
+
+// packParams returns the job parameters lifting images, counts, ports,
+// resources and env values into pack variables.
+func packParams() *jobParams {
+	lift := func(kind paramKind, attr string) bool {
+		// Packs parameterize whole images rather than their tags.
+		return kind != paramImageTag
+	}
+	return &jobParams{root: packVariableRoot, lift: lift}
+}
+
+// packJob is a job rendered as a pack template.
+type packJob struct {
+	name     string
+	template string
+}
+
+// renderPack lays out the pack directory for the given jobs as a map of file
+// name to content: metadata.hcl, variables.hcl, README.md and one template
+// per job. The variables are collected while rendering every job, so those
+// shared by several jobs, such as datacenters, are declared once.
+func renderPack(name string, jobs []*packJob, variables *jobParams) map[string]string {
+	files := make(map[string]string)
+	for _, job := range jobs {
+		files[path.Join(name, "templates", job.name+".nomad.tpl")] = packTemplate(job.template, variables)
+	}
+
+	files[path.Join(name, "metadata.hcl")] = packMetadata(name)
+	files[path.Join(name, "variables.hcl")] = packVariables(variables.variables)
+	files[path.Join(name, "README.md")] = packReadme(name, variables.variables)
+	return files
+}
+
+// packTemplate rewrites the variable references of a rendered job into pack
+// template calls, formatted according to the type of the variable.
+func packTemplate(hclOutput string, variables *jobParams) string {
+	return packVariableReferenceRegex.ReplaceAllStringFunc(hclOutput, func(reference string) string {
+		name := packVariableReferenceRegex.FindStringSubmatch(reference)[1]
+		call := fmt.Sprintf(`var "%s" .`, name)
+		if variable := variables.lookup(name); variable != nil {
+			switch {
+			case variable.value.Type() == cty.String:
+				call += " | quote"
+			case variable.value.Type().IsListType():
+				call += " | toStringList"
+			}
+		}
+		return "[[ " + call + " ]]"
+	})
+}
+
+// packMetadata renders the metadata.hcl of the pack.
+func packMetadata(name string) string {
+	hclFile := hclwrite.NewEmptyFile()
+	rootBody := hclFile.Body()
+
+	appBody := rootBody.AppendNewBlock("app", nil).Body()
+	appBody.SetAttributeValue("url", cty.StringVal(""))
+	rootBody.AppendNewline()
+
+	packBody := rootBody.AppendNewBlock("pack", nil).Body()
+	packBody.SetAttributeValue("name", cty.StringVal(name))
+	packBody.SetAttributeValue("description", cty.StringVal("Generated from a Docker Compose file by docker-compose-to-nomad."))
+	packBody.SetAttributeValue("version", cty.StringVal(packVersion))
+	return string(hclwrite.Format(hclFile.Bytes()))
+}
+
+// packVariables renders the variables.hcl of the pack.
+func packVariables(variables []*jobVariable) string {
+	hclFile := hclwrite.NewEmptyFile()
+	rootBody := hclFile.Body()
+	for i, variable := range variables {
+		if i > 0 {
+			rootBody.AppendNewline()
+		}
+		addVariableBlock(rootBody, variable, true)
+	}
+	return string(hclwrite.Format(hclFile.Bytes()))
+}
+
+// packReadme renders the README.md of the pack, listing its variables.
+func packReadme(name string, variables []*jobVariable) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", name)
+	sb.WriteString("This pack was generated from a Docker Compose file by docker-compose-to-nomad.\n")
+	sb.WriteString("The compose values are used as variable defaults.\n\n")
+	sb.WriteString("## Variables\n\n")
+	sb.WriteString("| Name | Description | Default |\n")
+	sb.WriteString("| ---- | ----------- | ------- |\n")
+	for _, variable := range variables {
+		defaultValue := strings.TrimSpace(string(hclwrite.TokensForValue(variable.value).Bytes()))
+		fmt.Fprintf(&sb, "| `%s` | %s | `%s` |\n", variable.name, variable.description, strings.ReplaceAll(defaultValue, "|", `\|`))
+	}
+	return sb.String()
+}
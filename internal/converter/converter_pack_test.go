@@ -0,0 +1,110 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+func TestConvertToNomadJobs_Pack(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(resourcesDockerComposeYAML, converter.Options{Format: converter.FormatPack})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	for _, name := range []string{"shop/metadata.hcl", "shop/variables.hcl", "shop/README.md", "shop/templates/shop.nomad.tpl"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected pack file %s, got %v", name, fileNames(files))
+		}
+	}
+
+	template := files["shop/templates/shop.nomad.tpl"]
+	expectedReferences := []string{
+		`datacenters = [[ var "datacenters" . | toStringList ]]`,
+		`count = [[ var "web_count" . ]]`,
+		`image = [[ var "web_image" . | quote ]]`,
+		`NGINX_HOST = [[ var "web_env_nginx_host" . | quote ]]`,
+		`static = [[ var "web_http_port" . ]]`,
+		`memory_max = [[ var "web_memory_max" . ]]`,
+		`memory = [[ var "worker_memory" . ]]`,
+	}
+	for _, reference := range expectedReferences {
+		if !regexp.MustCompile(regexp.QuoteMeta(reference)).MatchString(strings.Join(strings.Fields(template), " ")) {
+			t.Errorf("Pack template does not contain %s:\n%s", reference, template)
+		}
+	}
+
+	variables := files["shop/variables.hcl"]
+	webImagePattern := `variable "web_image" \{\s*description\s*=\s*"[^"]+"\s*type\s*=\s*string\s*default\s*=\s*"nginx:latest"\s*\}`
+	if !regexp.MustCompile(webImagePattern).MatchString(variables) {
+		t.Errorf("variables.hcl does not declare web_image with the compose image as default:\n%s", variables)
+	}
+	// Services without resources get Nomad's defaults so they can still be tuned.
+	workerCPUPattern := `variable "worker_cpu" \{[\s\S]*?type\s*=\s*number\s*default\s*=\s*100\s*\}`
+	if !regexp.MustCompile(workerCPUPattern).MatchString(variables) {
+		t.Errorf("variables.hcl does not declare worker_cpu with Nomad's default:\n%s", variables)
+	}
+	if !regexp.MustCompile(`name\s*=\s*"shop"`).MatchString(files["shop/metadata.hcl"]) {
+		t.Errorf("metadata.hcl does not name the pack:\n%s", files["shop/metadata.hcl"])
+	}
+	if !strings.Contains(files["shop/README.md"], "| `web_count` |") {
+		t.Errorf("README.md does not document the pack variables:\n%s", files["shop/README.md"])
+	}
+}
+
+func TestConvertToNomadJobs_PackVariableNameCollisions(t *testing.T) {
+	yaml := `
+name: shop
+services:
+  my-web:
+    image: web:1.0
+    environment:
+      DB-HOST: a
+      DB_HOST: b
+  my_web:
+    image: web:2.0
+`
+	files, err := converter.ConvertToNomadJobs(yaml, converter.Options{Format: converter.FormatPack, Split: converter.SplitService})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+
+	templates := map[string][]string{
+		"shop/templates/my-web.nomad.tpl": {
+			`image = [[ var "my_web_image" . | quote ]]`,
+			`DB-HOST = [[ var "my_web_env_db_host" . | quote ]]`,
+			`DB_HOST = [[ var "my_web_env_db_host_2" . | quote ]]`,
+		},
+		"shop/templates/my_web.nomad.tpl": {
+			`image = [[ var "my_web_image_2" . | quote ]]`,
+		},
+	}
+	for name, references := range templates {
+		template := strings.Join(strings.Fields(files[name]), " ")
+		for _, reference := range references {
+			if !strings.Contains(template, reference) {
+				t.Errorf("%s does not contain %s:\n%s", name, reference, files[name])
+			}
+		}
+	}
+
+	variables := files["shop/variables.hcl"]
+	defaults := map[string]string{
+		"my_web_image":         `"web:1.0"`,
+		"my_web_image_2":       `"web:2.0"`,
+		"my_web_env_db_host":   `"a"`,
+		"my_web_env_db_host_2": `"b"`,
+	}
+	for name, value := range defaults {
+		pattern := `variable "` + name + `" \{[^}]*default\s*=\s*` + regexp.QuoteMeta(value) + `\s*\}`
+		if !regexp.MustCompile(pattern).MatchString(variables) {
+			t.Errorf("variables.hcl does not declare %s with default %s:\n%s", name, value, variables)
+		}
+	}
+	if n := strings.Count(variables, `variable "datacenters"`); n != 1 {
+		t.Errorf("variables.hcl should declare datacenters once, got %d:\n%s", n, variables)
+	}
+}
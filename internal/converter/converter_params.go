@@ -0,0 +1,160 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// paramKind classifies the job values that can be lifted into variables.
+type paramKind int
+
+const (
+	paramDatacenters paramKind = iota
+	paramImage
+	paramImageTag
+	paramCount
+	paramPort
+	paramEnv
+	paramResources
+)
+
+// jobVariable is a job value lifted into a variable, with the compose value as default.
+type jobVariable struct {
+	name        string
+	key         string // Unsanitized name parts, telling apart values whose names collide
+	kind        paramKind
+	description string
+	value       cty.Value
+}
+
+// jobParams collects the job values lifted into variables while a job is
+// rendered, and writes references to them in place of the literal values.
+// A nil *jobParams writes every value literally.
+type jobParams struct {
+	// root is the name the variable references are rooted at, e.g. "var".
+	root string
+	// lift reports whether an attribute of the given kind is lifted into a
+	// variable. A nil lift lifts every value.
+	lift func(kind paramKind, attr string) bool
+	// declare lists the variables in `variable` blocks ahead of the job.
+	declare   bool
+	variables []*jobVariable
+}
+
+// includes reports whether the attribute of the given kind is lifted into a variable.
+func (p *jobParams) includes(kind paramKind, attr string) bool {
+	return p != nil && (p.lift == nil || p.lift(kind, attr))
+}
+
+// set writes value to the attribute of body, or a reference to a variable
+// holding it when the attribute is lifted. The variable name is built from
+// the given parts.
+func (p *jobParams) set(body *hclwrite.Body, attr string, kind paramKind, description string, value cty.Value, nameParts ...string) {
+	if !p.includes(kind, attr) {
+		body.SetAttributeValue(attr, value)
+		return
+	}
+	body.SetAttributeTraversal(attr, p.reference(kind, description, value, nameParts...))
+}
+
+// setInterpolated writes the string prefix+value to the attribute of body. When
+// the attribute is lifted, only value moves into a variable, which is
+// interpolated after the literal prefix (e.g. "nginx:${var.web_image_tag}").
+func (p *jobParams) setInterpolated(body *hclwrite.Body, attr string, kind paramKind, description string, prefix string, value string, nameParts ...string) {
+	if !p.includes(kind, attr) {
+		body.SetAttributeValue(attr, cty.StringVal(prefix+value))
+		return
+	}
+	traversal := p.reference(kind, description, cty.StringVal(value), nameParts...)
+	literal := hclwrite.TokensForValue(cty.StringVal(prefix))
+	// Keep the quoted literal of the prefix, dropping its closing quote.
+	tokens := append(hclwrite.Tokens{}, literal[:len(literal)-1]...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")})
+	tokens = append(tokens, hclwrite.TokensForTraversal(traversal)...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")})
+	tokens = append(tokens, literal[len(literal)-1])
+	body.SetAttributeRaw(attr, tokens)
+}
+
+// reference records the variable holding value and returns a reference to it.
+// Values whose name parts sanitize to the name of another value, such as the
+// env vars DB-HOST and DB_HOST, get a numbered name instead, e.g. "db_host_2".
+func (p *jobParams) reference(kind paramKind, description string, value cty.Value, nameParts ...string) hcl.Traversal {
+	key := strings.Join(nameParts, "\x00")
+	base := variableName(nameParts...)
+	name := base
+	for n := 2; ; n++ {
+		variable := p.lookup(name)
+		if variable == nil {
+			p.variables = append(p.variables, &jobVariable{name: name, key: key, kind: kind, description: description, value: value})
+			break
+		}
+		if variable.key == key {
+			break
+		}
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+	return hcl.Traversal{
+		hcl.TraverseRoot{Name: p.root},
+		hcl.TraverseAttr{Name: name},
+	}
+}
+
+// lookup returns the collected variable with the given name, if any.
+func (p *jobParams) lookup(name string) *jobVariable {
+	for _, variable := range p.variables {
+		if variable.name == name {
+			return variable
+		}
+	}
+	return nil
+}
+
+// variableName joins the given parts into a valid variable name such as
+// "web_env_nginx_host". Identifiers cannot start with a digit, so names that
+// would are prefixed with an underscore.
+func variableName(parts ...string) string {
+	sanitized := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if s := portutils.SanitizeCommentToLabel(part); s != "" {
+			sanitized = append(sanitized, s)
+		}
+	}
+	name := strings.Join(sanitized, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// variableTypeTokens returns the HCL type constraint of a variable's value.
+func variableTypeTokens(value cty.Value) hclwrite.Tokens {
+	switch {
+	case value.Type() == cty.Number:
+		return hclwrite.TokensForIdentifier("number")
+	case value.Type() == cty.Bool:
+		return hclwrite.TokensForIdentifier("bool")
+	case value.Type().IsListType() || value.Type().IsTupleType():
+		return hclwrite.TokensForFunctionCall("list", hclwrite.TokensForIdentifier("string"))
+	default:
+		return hclwrite.TokensForIdentifier("string")
+	}
+}
+
+// addVariableBlock appends an HCL2 `variable` block declaring the variable to
+// body. The compose value becomes the default when withDefault is set.
+func addVariableBlock(body *hclwrite.Body, variable *jobVariable, withDefault bool) {
+	variableBody := body.AppendNewBlock("variable", []string{variable.name}).Body()
+	variableBody.SetAttributeValue("description", cty.StringVal(variable.description))
+	variableBody.SetAttributeRaw("type", variableTypeTokens(variable.value))
+	if withDefault {
+		variableBody.SetAttributeValue("default", variable.value)
+	}
+}
@@ -0,0 +1,383 @@
+package converter
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/cronexpr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// ofeliaLabelPrefix prefixes the labels ofelia reads its jobs from, as
+// "ofelia.<type>.<name>.<key>".
+const ofeliaLabelPrefix = "ofelia."
+
+// isOfeliaLabel reports whether a label configures ofelia.
+func isOfeliaLabel(key string) bool {
+	return strings.HasPrefix(key, ofeliaLabelPrefix)
+}
+
+// isOfeliaScheduler reports whether a service runs the ofelia scheduler,
+// whose jobs become periodic jobs.
+func isOfeliaScheduler(service dockercompose.Service) bool {
+	name := service.Image
+	if prefix, _, ok := splitImageTag(name); ok {
+		name = strings.TrimSuffix(prefix, ":")
+	}
+	name, _, _ = strings.Cut(name, "@")
+	return path.Base(name) == "ofelia"
+}
+
+// ofeliaJob is a job declared by ofelia labels.
+type ofeliaJob struct {
+	jobType  string // "job-exec", "job-run", "job-service-run" or "job-local"
+	name     string
+	settings map[string]string
+}
+
+// ofeliaJobs returns the ofelia jobs declared by labels, sorted by name.
+func ofeliaJobs(labels map[string]string) []ofeliaJob {
+	jobsByKey := make(map[string]*ofeliaJob)
+	for key, value := range labels {
+		rest, ok := strings.CutPrefix(key, ofeliaLabelPrefix)
+		if !ok {
+			continue
+		}
+		jobType, rest, ok := strings.Cut(rest, ".")
+		if !ok || !strings.HasPrefix(jobType, "job-") {
+			continue
+		}
+		dot := strings.LastIndex(rest, ".")
+		if dot <= 0 {
+			continue
+		}
+		name, setting := rest[:dot], rest[dot+1:]
+		job, ok := jobsByKey[jobType+"."+name]
+		if !ok {
+			job = &ofeliaJob{jobType: jobType, name: name, settings: make(map[string]string)}
+			jobsByKey[jobType+"."+name] = job
+		}
+		job.settings[setting] = value
+	}
+	jobs := make([]ofeliaJob, 0, len(jobsByKey))
+	for _, job := range jobsByKey {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].name != jobs[j].name {
+			return jobs[i].name < jobs[j].name
+		}
+		return jobs[i].jobType < jobs[j].jobType
+	})
+	return jobs
+}
+
+// ofeliaSettings are the job settings carried over to periodic jobs.
+var ofeliaSettings = map[string]bool{"schedule": true, "command": true, "image": true, "container": true, "user": true, "no-overlap": true}
+
+// periodicPlan describes a periodic batch job running an ofelia job.
+type periodicPlan struct {
+	name      string // Group and task name
+	service   dockercompose.Service
+	cron      string
+	noOverlap bool
+	notes     []string
+}
+
+// planPeriodic turns the ofelia jobs declared in service labels into periodic
+// job plans. `job-exec` jobs run their command in a new container of the
+// target service's image instead of the running container, `job-run` and
+// `job-service-run` jobs in a container of their image or of the named
+// service. The ofelia scheduler services are returned to be dropped, unless
+// they declare `job-local` jobs, which run in the scheduler itself; notes on
+// the remaining services are returned by service name.
+func planPeriodic(dc *dockercompose.DockerCompose) ([]*periodicPlan, []string, map[string][]string, error) {
+	serviceNames := make([]string, 0, len(dc.Services))
+	for serviceName := range dc.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	var plans []*periodicPlan
+	var schedulers []string
+	notes := make(map[string][]string)
+	planned := make(map[string]string)
+	for _, serviceName := range serviceNames {
+		service := dc.Services[serviceName]
+		scheduler := isOfeliaScheduler(service)
+		keepScheduler := false
+		for _, job := range ofeliaJobs(parseKeyValues(service.Labels)) {
+			describe := fmt.Sprintf("ofelia %s '%s' of service '%s'", job.jobType, job.name, serviceName)
+			if job.jobType == "job-local" {
+				notes[serviceName] = append(notes[serviceName], fmt.Sprintf("The %s runs in the scheduler container and is not converted; the scheduler is kept for it.", describe))
+				keepScheduler = true
+				continue
+			}
+
+			target, template := serviceName, service
+			if container := job.settings["container"]; container != "" {
+				targetName, found := serviceByNameOrContainer(dc, container)
+				if !found {
+					return nil, nil, nil, fmt.Errorf("%s runs in container '%s', which is not a service of the compose file", describe, container)
+				}
+				target, template = targetName, dc.Services[targetName]
+			} else if job.jobType == "job-exec" && scheduler {
+				return nil, nil, nil, fmt.Errorf("%s names no container to run in", describe)
+			}
+
+			plan := &periodicPlan{name: target + "-" + job.name}
+			switch job.jobType {
+			case "job-exec":
+				// docker exec runs the command without the image entrypoint.
+				args := splitCommand(job.settings["command"])
+				if len(args) == 0 {
+					return nil, nil, nil, fmt.Errorf("%s has no command", describe)
+				}
+				template.Entrypoint = stringsToAny(args)
+				template.Command = nil
+				plan.notes = append(plan.notes, fmt.Sprintf("Runs the %s in a new container of the '%s' image instead of the running container.", describe, target))
+			case "job-run", "job-service-run":
+				if image := job.settings["image"]; image != "" {
+					template = dockercompose.Service{Image: image}
+					plan.name = job.name
+				} else if job.settings["container"] == "" {
+					return nil, nil, nil, fmt.Errorf("%s names no image or container to run", describe)
+				}
+				if command := job.settings["command"]; command != "" {
+					template.Command = stringsToAny(splitCommand(command))
+				}
+				plan.notes = append(plan.notes, fmt.Sprintf("Runs the %s.", describe))
+			default:
+				return nil, nil, nil, fmt.Errorf("%s has an unknown job type", describe)
+			}
+
+			cron, err := ofeliaCron(job.settings["schedule"])
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("%s: %w", describe, err)
+			}
+			if cron == "" {
+				// Skip the job like job-local rather than fail the conversion.
+				advice := "use a cron schedule"
+				if scheduler {
+					advice = "the scheduler is kept for it"
+					keepScheduler = true
+				}
+				notes[serviceName] = append(notes[serviceName], fmt.Sprintf("The %s runs %s, which cron cannot express, and is not converted; %s.", describe, strings.TrimSpace(job.settings["schedule"]), advice))
+				continue
+			}
+			plan.cron = cron
+			plan.noOverlap, _ = strconv.ParseBool(job.settings["no-overlap"])
+			if user := job.settings["user"]; user != "" {
+				template.User = user
+			}
+			for _, setting := range sortedKeys(job.settings) {
+				if !ofeliaSettings[setting] {
+					plan.notes = append(plan.notes, fmt.Sprintf("ofelia setting '%s' is not converted.", setting))
+				}
+			}
+			plan.service = periodicService(template)
+
+			if other, ok := planned[plan.name]; ok {
+				return nil, nil, nil, fmt.Errorf("%s and %s both become periodic job '%s'", other, describe, plan.name)
+			}
+			if _, ok := dc.Services[plan.name]; ok {
+				return nil, nil, nil, fmt.Errorf("%s becomes periodic job '%s', which is also a service name", describe, plan.name)
+			}
+			planned[plan.name] = describe
+			plans = append(plans, plan)
+		}
+		if scheduler && !keepScheduler {
+			schedulers = append(schedulers, serviceName)
+		}
+	}
+	if len(plans) == 0 {
+		// Without jobs to take over, the scheduler is converted as is.
+		schedulers = nil
+	}
+	return plans, schedulers, notes, nil
+}
+
+// periodicService returns the service a periodic job runs, without the
+// settings of the long-running service it is derived from: ports, networks,
+// dependencies, routing labels, deploy settings other than resources and the
+// restart policy.
+func periodicService(service dockercompose.Service) dockercompose.Service {
+	periodic := service
+	labels := containerLabels(service)
+	delete(labels, oneShotLabel)
+	periodic.Labels = labels
+	periodic.Ports = nil
+	periodic.Networks = nil
+	periodic.Links = nil
+	periodic.DependsOn = nil
+	periodic.Restart = ""
+	periodic.ContainerName = ""
+	periodic.XNomadJob = ""
+	periodic.XNomadOneshot = nil
+	if _, ok := sharedNetworkTarget(service); ok {
+		periodic.NetworkMode = ""
+	}
+	periodic.Deploy = nil
+	if service.Deploy != nil && service.Deploy.Resources != nil {
+		periodic.Deploy = &dockercompose.Deploy{Resources: service.Deploy.Resources}
+	}
+	return periodic
+}
+
+// ofeliaCron translates an ofelia schedule into a Nomad cron expression.
+// Ofelia schedules may start with a seconds field, which is dropped when
+// zero; `@every` intervals are translated when they divide a minute, an hour
+// or a day evenly. Other intervals return an empty expression, leaving the
+// ofelia job unconverted.
+func ofeliaCron(schedule string) (string, error) {
+	schedule = strings.TrimSpace(schedule)
+	var cron string
+	switch {
+	case schedule == "":
+		return "", fmt.Errorf("no schedule")
+	case strings.HasPrefix(schedule, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(schedule, "@every ")))
+		if err != nil {
+			return "", fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+		cron = everyCron(interval)
+		if cron == "" {
+			return "", nil
+		}
+	case schedule == "@midnight":
+		cron = "@daily"
+	case strings.HasPrefix(schedule, "@"):
+		cron = schedule
+	default:
+		fields := strings.Fields(schedule)
+		switch len(fields) {
+		case 5:
+			cron = strings.Join(fields, " ")
+		case 6:
+			if fields[0] == "0" {
+				cron = strings.Join(fields[1:], " ")
+			} else {
+				// A seconds field is only read with a year field.
+				cron = strings.Join(append(fields, "*"), " ")
+			}
+		default:
+			return "", fmt.Errorf("schedule %q is not a cron expression with 5 or 6 fields", schedule)
+		}
+	}
+	if _, err := cronexpr.Parse(cron); err != nil {
+		return "", fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return cron, nil
+}
+
+// everyCron returns the cron expression running every interval, or an empty
+// string when cron cannot express it.
+func everyCron(interval time.Duration) string {
+	switch {
+	case interval <= 0:
+		return ""
+	case interval == 7*24*time.Hour:
+		return "@weekly"
+	case interval == 24*time.Hour:
+		return "@daily"
+	case interval == time.Hour:
+		return "@hourly"
+	case interval%time.Hour == 0 && 24%int(interval/time.Hour) == 0:
+		return fmt.Sprintf("0 */%d * * *", interval/time.Hour)
+	case interval%time.Minute == 0 && 60%int(interval/time.Minute) == 0:
+		return fmt.Sprintf("*/%d * * * *", interval/time.Minute)
+	case interval%time.Second == 0 && 60%int(interval/time.Second) == 0:
+		return fmt.Sprintf("*/%d * * * * * *", interval/time.Second)
+	}
+	return ""
+}
+
+// splitCommand splits a command line into arguments, honoring single and
+// double quotes and backslash escapes as ofelia does.
+func splitCommand(command string) []string {
+	var args []string
+	var current strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+	for _, c := range command {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// stringsToAny returns a string list as a compose list value.
+func stringsToAny(values []string) []any {
+	list := make([]any, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// addPeriodicJobs adds the services of the periodic plans to the compose file
+// and the batch jobs running them, named after the default job, to the
+// planned jobs.
+func addPeriodicJobs(dc *dockercompose.DockerCompose, opts Options, jobs []*jobPlan, plans []*periodicPlan) ([]*jobPlan, error) {
+	jobNames := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		jobNames[job.name] = true
+	}
+	for _, plan := range plans {
+		jobName := defaultJobNameFor(dc, opts) + "-" + plan.name
+		if jobNames[jobName] {
+			return nil, fmt.Errorf("periodic job '%s' has the name of another job", jobName)
+		}
+		jobNames[jobName] = true
+		dc.Services[plan.name] = plan.service
+		jobs = append(jobs, &jobPlan{
+			name:     jobName,
+			jobType:  jobTypeBatch,
+			groups:   []*groupPlan{{name: plan.name, notes: plan.notes}},
+			periodic: plan,
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].name < jobs[j].name })
+	return jobs, nil
+}
+
+// addPeriodic writes the periodic block of a job running an ofelia job.
+func addPeriodic(jobBody *hclwrite.Body, plan *periodicPlan) {
+	periodicBody := jobBody.AppendNewBlock("periodic", nil).Body()
+	periodicBody.SetAttributeValue("crons", cty.ListVal([]cty.Value{cty.StringVal(plan.cron)}))
+	if plan.noOverlap {
+		periodicBody.SetAttributeValue("prohibit_overlap", cty.True)
+	}
+}
@@ -0,0 +1,193 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const periodicDockerComposeYAML = `
+name: app
+services:
+  web:
+    image: app:1.2
+    ports:
+      - "80:80"
+    restart: always
+    environment:
+      DATABASE_URL: postgres://db/app
+    labels:
+      ofelia.enabled: "true"
+      ofelia.job-exec.reindex.schedule: "0 */15 * * * *"
+      ofelia.job-exec.reindex.command: "php artisan 'scout:import' --all"
+      ofelia.job-exec.reindex.no-overlap: "true"
+      traefik.enable: "true"
+  scheduler:
+    image: mcuadros/ofelia:latest
+    command: daemon --docker
+    volumes:
+      - /var/run/docker.sock:/var/run/docker.sock:ro
+    labels:
+      ofelia.job-run.backup.schedule: "@every 6h"
+      ofelia.job-run.backup.image: "postgres:16"
+      ofelia.job-run.backup.command: "pg_dump -h db app"
+      ofelia.job-run.cleanup.schedule: "30 0 3 * * *"
+      ofelia.job-run.cleanup.container: web
+      ofelia.job-run.cleanup.command: "php artisan cleanup"
+      ofelia.job-run.cleanup.delete: "true"
+`
+
+func TestConvertToNomadJobs_Periodic(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(periodicDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("Expected the app job and three periodic jobs, got %v", fileNames(files))
+	}
+
+	checks := map[string]map[string]string{
+		"app-web-reindex.nomad.hcl": {
+			"batch type":      `type\s*=\s*"batch"`,
+			"seconds dropped": `periodic\s*\{\s*crons\s*=\s*\["\*/15 \* \* \* \*"\]\s*prohibit_overlap\s*=\s*true\s*\}`,
+			"exec command":    `command\s*=\s*"php"\s*args\s*=\s*\["artisan", "scout:import", "--all"\]`,
+			"environment":     `DATABASE_URL\s*=\s*"postgres://db/app"`,
+			"exec note":       `# Runs the ofelia job-exec 'reindex' of service 'web' in a new container of the 'web' image instead of the running container\.`,
+		},
+		"app-backup.nomad.hcl": {
+			"every interval": `crons\s*=\s*\["0 \*/6 \* \* \*"\]`,
+			"image":          `image\s*=\s*"postgres:16"`,
+			"command":        `command\s*=\s*"pg_dump"\s*args\s*=\s*\["-h", "db", "app"\]`,
+		},
+		"app-web-cleanup.nomad.hcl": {
+			"seconds kept":    `crons\s*=\s*\["30 0 3 \* \* \* \*"\]`,
+			"service image":   `image\s*=\s*"app:1.2"`,
+			"ignored setting": `# ofelia setting 'delete' is not converted\.`,
+		},
+	}
+	for file, patterns := range checks {
+		hcl := files[file]
+		if hcl == "" {
+			t.Fatalf("Expected %s, got %v", file, fileNames(files))
+		}
+		for name, pattern := range patterns {
+			if !regexp.MustCompile(pattern).MatchString(hcl) {
+				t.Errorf("%s does not contain %s:\n%s", file, name, hcl)
+			}
+		}
+		for _, unwanted := range []string{"network", "restart", "traefik", "ofelia.", "prohibit_overlap = false"} {
+			if strings.Contains(hcl, unwanted) {
+				t.Errorf("%s should not contain %q:\n%s", file, unwanted, hcl)
+			}
+		}
+	}
+
+	app := files["app.nomad.hcl"]
+	if strings.Contains(app, "scheduler") || strings.Contains(app, "ofelia") {
+		t.Errorf("The ofelia scheduler and labels should be dropped:\n%s", app)
+	}
+}
+
+func TestConvertToNomadJobs_PeriodicLocalJob(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(`
+services:
+  scheduler:
+    image: mcuadros/ofelia
+    labels:
+      ofelia.job-local.prune.schedule: "@hourly"
+      ofelia.job-local.prune.command: "docker system prune -f"
+`, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hcl := files["my-docker-compose-job.nomad.hcl"]
+	if !strings.Contains(hcl, `group "scheduler"`) || !strings.Contains(hcl, "# The ofelia job-local 'prune' of service 'scheduler' runs in the scheduler container and is not converted; the scheduler is kept for it.") {
+		t.Errorf("Expected the scheduler to be kept with a note, got %v:\n%s", fileNames(files), hcl)
+	}
+}
+
+func TestConvertToNomadJobs_PeriodicUnevenInterval(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(`
+services:
+  web:
+    image: app
+    labels:
+      ofelia.job-exec.report.schedule: "@every 90m"
+      ofelia.job-exec.report.command: report
+  scheduler:
+    image: mcuadros/ofelia
+    labels:
+      ofelia.job-run.backup.schedule: "@every 1h30m"
+      ofelia.job-run.backup.image: backup
+      ofelia.job-run.cleanup.schedule: "@daily"
+      ofelia.job-run.cleanup.image: cleanup
+`, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if _, ok := files["my-docker-compose-job-cleanup.nomad.hcl"]; !ok {
+		t.Errorf("Expected the other ofelia jobs to be converted, got %v", fileNames(files))
+	}
+	hcl := files["my-docker-compose-job.nomad.hcl"]
+	checks := map[string]string{
+		"service note":   `group "web"[\s\S]*?# The ofelia job-exec 'report' of service 'web' runs @every 90m, which cron cannot express, and is not converted; use a cron schedule.`,
+		"scheduler note": `group "scheduler"[\s\S]*?# The ofelia job-run 'backup' of service 'scheduler' runs @every 1h30m, which cron cannot express, and is not converted; the scheduler is kept for it.`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hcl) {
+			t.Errorf("HCL output does not contain the %s, got %v:\n%s", name, fileNames(files), hcl)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_PeriodicErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   string
+		expected string
+	}{
+		{
+			name: "invalid schedule",
+			labels: `
+      ofelia.job-exec.report.schedule: "0 25 * * *"
+      ofelia.job-exec.report.command: report`,
+			expected: `ofelia job-exec 'report' of service 'web': invalid schedule "0 25 * * *"`,
+		},
+		{
+			name: "missing schedule",
+			labels: `
+      ofelia.job-exec.report.command: report`,
+			expected: "ofelia job-exec 'report' of service 'web': no schedule",
+		},
+		{
+			name: "missing command",
+			labels: `
+      ofelia.job-exec.report.schedule: "@daily"`,
+			expected: "ofelia job-exec 'report' of service 'web' has no command",
+		},
+		{
+			name: "unknown container",
+			labels: `
+      ofelia.job-run.report.schedule: "@daily"
+      ofelia.job-run.report.container: worker`,
+			expected: "runs in container 'worker', which is not a service of the compose file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := converter.ConvertToNomadJobs(`
+services:
+  web:
+    image: app
+    labels:`+tt.labels+`
+`, converter.Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
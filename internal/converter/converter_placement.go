@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// defaultPlacementAttributes maps the Swarm node attributes of placement
+// constraints and preferences to Nomad node attributes. Node and engine
+// labels map to node meta; node.role has no Nomad equivalent.
+var defaultPlacementAttributes = map[string]string{
+	"node.id":            "${node.unique.id}",
+	"node.hostname":      "${attr.unique.hostname}",
+	"node.platform.os":   "${attr.kernel.name}",
+	"node.platform.arch": "${attr.cpu.arch}",
+}
+
+// Prefixes of the Swarm label attributes mapped to node meta.
+var placementLabelPrefixes = []string{"node.labels.", "engine.labels."}
+
+// placementArchitectures maps the architectures Swarm reports to the ones
+// Nomad fingerprints.
+var placementArchitectures = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+	"armv7l":  "arm",
+}
+
+// placementAttribute returns the Nomad attribute a Swarm node attribute maps
+// to, looking up the configured mapping before the default one. Configured
+// attributes may omit the interpolation braces.
+func (r *jobRenderer) placementAttribute(attribute string) (string, bool) {
+	if mapped, ok := r.opts.PlacementAttributes[attribute]; ok {
+		if !strings.HasPrefix(mapped, "${") {
+			mapped = "${" + mapped + "}"
+		}
+		return mapped, true
+	}
+	if mapped, ok := defaultPlacementAttributes[attribute]; ok {
+		return mapped, true
+	}
+	for _, prefix := range placementLabelPrefixes {
+		if key, ok := strings.CutPrefix(attribute, prefix); ok && key != "" {
+			return "${meta." + key + "}", true
+		}
+	}
+	return "", false
+}
+
+// addPlacement writes the deploy.placement of the owning service as group
+// constraints and spreads. Rules that cannot be translated are noted.
+func (r *jobRenderer) addPlacement(groupBody *hclwrite.Body, group *groupPlan) {
+	for _, sidecarName := range group.sidecars {
+		if deploy := r.dc.Services[sidecarName].Deploy; deploy != nil && deploy.Placement != nil {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Service '%s' shares the network namespace of '%s' and is placed with it; its deploy.placement is ignored.", sidecarName, group.name)))
+		}
+	}
+	deploy := r.dc.Services[group.name].Deploy
+	if deploy == nil || deploy.Placement == nil {
+		return
+	}
+	placement := deploy.Placement
+	unmappedNote := func(attribute string) {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Swarm attribute %s has no Nomad equivalent; map it to a node attribute, e.g. ${node.class}, to keep the rule.", attribute)))
+	}
+
+	for _, constraint := range placement.Constraints {
+		attribute, operator, value, ok := parsePlacementConstraint(constraint)
+		if !ok {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Placement constraint '%s' is not of the form attribute==value or attribute!=value and is dropped.", constraint)))
+			continue
+		}
+		target, mapped := r.placementAttribute(attribute)
+		if !mapped {
+			unmappedNote(attribute)
+			continue
+		}
+		if attribute == "node.platform.arch" {
+			if arch, known := placementArchitectures[value]; known {
+				value = arch
+			}
+		}
+		constraintBody := groupBody.AppendNewBlock("constraint", nil).Body()
+		constraintBody.SetAttributeRaw("attribute", interpolationTokens(target))
+		if operator == "!=" {
+			constraintBody.SetAttributeValue("operator", cty.StringVal("!="))
+		}
+		constraintBody.SetAttributeValue("value", cty.StringVal(value))
+	}
+
+	// System jobs run on every eligible node, so there is nothing to spread or
+	// limit per node.
+	systemJob := isSystemJobType(r.job.jobType)
+	for _, preference := range placement.Preferences {
+		if preference.Spread == "" {
+			continue
+		}
+		if systemJob {
+			groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("Service '%s' runs on every eligible node in a %s job; its spread over %s is ignored.", group.name, r.job.jobType, preference.Spread)))
+			continue
+		}
+		target, mapped := r.placementAttribute(preference.Spread)
+		if !mapped {
+			unmappedNote(preference.Spread)
+			continue
+		}
+		groupBody.AppendNewBlock("spread", nil).Body().SetAttributeRaw("attribute", interpolationTokens(target))
+	}
+
+	if placement.MaxReplicas == nil || *placement.MaxReplicas <= 0 || systemJob {
+		return
+	}
+	constraintBody := groupBody.AppendNewBlock("constraint", nil).Body()
+	if *placement.MaxReplicas == 1 {
+		constraintBody.SetAttributeValue("operator", cty.StringVal("distinct_hosts"))
+		constraintBody.SetAttributeValue("value", cty.StringVal("true"))
+		return
+	}
+	constraintBody.SetAttributeRaw("attribute", interpolationTokens("${node.unique.id}"))
+	constraintBody.SetAttributeValue("operator", cty.StringVal("distinct_property"))
+	constraintBody.SetAttributeValue("value", cty.StringVal(strconv.Itoa(*placement.MaxReplicas)))
+}
+
+// parsePlacementConstraint splits a Swarm placement constraint such as
+// "node.labels.zone == east" into its attribute, operator and value.
+func parsePlacementConstraint(constraint string) (attribute, operator, value string, ok bool) {
+	for _, operator := range []string{"==", "!="} {
+		if attribute, value, found := strings.Cut(constraint, operator); found {
+			attribute, value = strings.TrimSpace(attribute), strings.TrimSpace(value)
+			return attribute, operator, value, attribute != "" && value != ""
+		}
+	}
+	return "", "", "", false
+}
+
+// interpolationTokens returns the tokens of a quoted string holding a Nomad
+// runtime interpolation such as "${meta.zone}", which Nomad resolves on the
+// node instead of when the job is parsed.
+func interpolationTokens(interpolation string) hclwrite.Tokens {
+	return hclwrite.Tokens{
+		{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)},
+		{Type: hclsyntax.TokenQuotedLit, Bytes: []byte(interpolation)},
+		{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)},
+	}
+}
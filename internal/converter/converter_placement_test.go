@@ -0,0 +1,86 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const placementDockerComposeYAML = `
+services:
+  web:
+    image: web
+    deploy:
+      replicas: 3
+      placement:
+        constraints:
+          - node.labels.zone == east
+          - node.hostname != edge-1
+          - node.platform.arch == x86_64
+          - node.role == worker
+        preferences:
+          - spread: node.labels.rack
+        max_replicas_per_node: 1
+  worker:
+    image: worker
+    deploy:
+      replicas: 4
+      placement:
+        constraints:
+          - node.id
+        max_replicas_per_node: 2
+`
+
+func TestConvertToNomadJobs_Placement(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(placementDockerComposeYAML, converter.Options{JobName: "placement"})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hcl := files["placement.nomad.hcl"]
+
+	checks := map[string]string{
+		"label constraint":        `constraint \{\s*attribute\s*=\s*"\$\{meta\.zone\}"\s*value\s*=\s*"east"\s*\}`,
+		"hostname constraint":     `constraint \{\s*attribute\s*=\s*"\$\{attr\.unique\.hostname\}"\s*operator\s*=\s*"!="\s*value\s*=\s*"edge-1"\s*\}`,
+		"architecture constraint": `constraint \{\s*attribute\s*=\s*"\$\{attr\.cpu\.arch\}"\s*value\s*=\s*"amd64"\s*\}`,
+		"role note":               `# Swarm attribute node\.role has no Nomad equivalent`,
+		"spread":                  `spread \{\s*attribute\s*=\s*"\$\{meta\.rack\}"\s*\}`,
+		"distinct hosts":          `constraint \{\s*operator\s*=\s*"distinct_hosts"\s*value\s*=\s*"true"\s*\}`,
+		"distinct property":       `constraint \{\s*attribute\s*=\s*"\$\{node\.unique\.id\}"\s*operator\s*=\s*"distinct_property"\s*value\s*=\s*"2"\s*\}`,
+		"invalid constraint note": `# Placement constraint 'node\.id' is not of the form attribute==value`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hcl) {
+			t.Errorf("Expected %s in output:\n%s", name, hcl)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_PlacementAttributes(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(placementDockerComposeYAML, converter.Options{
+		JobName: "placement",
+		PlacementAttributes: map[string]string{
+			"node.role":        "node.class",
+			"node.labels.zone": "${attr.platform.aws.placement.availability-zone}",
+		},
+	})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hcl := files["placement.nomad.hcl"]
+
+	for _, expected := range []string{
+		`attribute = "${node.class}"`,
+		`attribute = "${attr.platform.aws.placement.availability-zone}"`,
+	} {
+		if !strings.Contains(hcl, expected) {
+			t.Errorf("Expected %s in output:\n%s", expected, hcl)
+		}
+	}
+	if strings.Contains(hcl, "node.role has no Nomad equivalent") {
+		t.Errorf("Mapped attribute should not be noted:\n%s", hcl)
+	}
+}
@@ -0,0 +1,199 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+const (
+	// mhzPerCPU converts compose CPU counts to Nomad's MHz based cpu resource.
+	mhzPerCPU = 1000
+	// defaultCPU and defaultMemory are Nomad's task resource defaults.
+	defaultCPU    = 100
+	defaultMemory = 300
+)
+
+// byteUnits maps compose byte size suffixes to their multiplier.
+var byteUnits = map[string]int64{
+	"":   1,
+	"b":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+	"t":  1 << 40,
+	"tb": 1 << 40,
+}
+
+// parseByteSize parses a compose byte size such as "512m", "2gb" or 1024 into bytes.
+func parseByteSize(raw any) (int64, error) {
+	switch typed := raw.(type) {
+	case int:
+		return int64(typed), nil
+	case int64:
+		return typed, nil
+	case float64:
+		return int64(typed), nil
+	case string:
+		s := strings.ToLower(strings.TrimSpace(typed))
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		number, unit := s, ""
+		if i >= 0 {
+			number, unit = s[:i], strings.TrimSpace(s[i:])
+		}
+		multiplier, ok := byteUnits[unit]
+		if !ok || number == "" {
+			return 0, fmt.Errorf("invalid byte size '%s'", typed)
+		}
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size '%s': %w", typed, err)
+		}
+		return int64(value * float64(multiplier)), nil
+	}
+	return 0, fmt.Errorf("invalid byte size '%v'", raw)
+}
+
+// formatByteSize formats a number of bytes as a compose byte size using the
+// largest unit dividing it exactly, e.g. 2147483648 as "2g".
+func formatByteSize(bytes int64) string {
+	for _, unit := range []string{"t", "g", "m", "k"} {
+		if multiplier := byteUnits[unit]; bytes != 0 && bytes%multiplier == 0 {
+			return fmt.Sprintf("%d%s", bytes/multiplier, unit)
+		}
+	}
+	return fmt.Sprintf("%d", bytes)
+}
+
+// parseCPUs parses a compose CPU count such as "0.5" or 2.
+func parseCPUs(raw any) (float64, error) {
+	switch typed := raw.(type) {
+	case int:
+		return float64(typed), nil
+	case float64:
+		return typed, nil
+	case string:
+		value, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpus '%s': %w", typed, err)
+		}
+		return value, nil
+	}
+	return 0, fmt.Errorf("invalid cpus '%v'", raw)
+}
+
+// taskResources holds the Nomad resources derived from a compose service.
+// Zero values mean the service does not specify the resource.
+type taskResources struct {
+	cpu       int64 // MHz
+	memory    int64 // MB
+	memoryMax int64 // MB
+	notes     []string
+}
+
+// serviceResources maps the compose resource keys of a service to Nomad task
+// resources. Reservations become the scheduled resources, limits become the
+// memory ceiling (or the scheduled memory when nothing is reserved).
+func serviceResources(service dockercompose.Service) taskResources {
+	var res taskResources
+	var limits, reservations dockercompose.ResourceSpec
+	if service.Deploy != nil && service.Deploy.Resources != nil {
+		if service.Deploy.Resources.Limits != nil {
+			limits = *service.Deploy.Resources.Limits
+		}
+		if service.Deploy.Resources.Reservations != nil {
+			reservations = *service.Deploy.Resources.Reservations
+		}
+	}
+	if limits.Cpus == nil {
+		limits.Cpus = service.Cpus
+	}
+	if limits.Memory == nil {
+		limits.Memory = service.MemLimit
+	}
+	if reservations.Memory == nil {
+		reservations.Memory = service.MemReservation
+	}
+
+	cpus := reservations.Cpus
+	if cpus == nil {
+		cpus = limits.Cpus
+	}
+	if cpus != nil {
+		value, err := parseCPUs(cpus)
+		if err != nil {
+			res.notes = append(res.notes, err.Error())
+		} else {
+			res.cpu = int64(value * mhzPerCPU)
+			res.notes = append(res.notes, fmt.Sprintf("cpu is in MHz; %v compose CPUs converted at %d MHz per CPU.", cpus, mhzPerCPU))
+		}
+	}
+
+	reserved := megabytes(reservations.Memory, &res)
+	limit := megabytes(limits.Memory, &res)
+	switch {
+	case reserved > 0 && limit > reserved:
+		res.memory, res.memoryMax = reserved, limit
+	case reserved > 0:
+		res.memory = reserved
+	default:
+		res.memory = limit
+	}
+	return res
+}
+
+// megabytes converts a compose byte size to whole megabytes, rounding up.
+// Parse errors are recorded as notes on the resources.
+func megabytes(raw any, res *taskResources) int64 {
+	if raw == nil {
+		return 0
+	}
+	bytes, err := parseByteSize(raw)
+	if err != nil {
+		res.notes = append(res.notes, err.Error())
+		return 0
+	}
+	return (bytes + (1<<20 - 1)) >> 20
+}
+
+// addResources appends the task resources derived from the compose resource
+// keys. When resources are lifted into variables, Nomad's defaults stand in
+// for values the service does not specify.
+func (r *jobRenderer) addResources(taskBody *hclwrite.Body, taskName string, service dockercompose.Service) {
+	res := serviceResources(service)
+	if r.params.includes(paramResources, "resources") {
+		if res.cpu == 0 {
+			res.cpu = defaultCPU
+		}
+		if res.memory == 0 {
+			res.memory = defaultMemory
+		}
+	}
+	if res.cpu == 0 && res.memory == 0 && len(res.notes) == 0 {
+		return
+	}
+
+	resourcesBody := taskBody.AppendNewBlock("resources", nil).Body()
+	for _, note := range res.notes {
+		resourcesBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	if res.cpu > 0 {
+		r.params.set(resourcesBody, "cpu", paramResources, fmt.Sprintf("CPU in MHz reserved for the %s task.", taskName), cty.NumberIntVal(res.cpu), taskName, "cpu")
+	}
+	if res.memory > 0 {
+		r.params.set(resourcesBody, "memory", paramResources, fmt.Sprintf("Memory in MB reserved for the %s task.", taskName), cty.NumberIntVal(res.memory), taskName, "memory")
+	}
+	if res.memoryMax > 0 {
+		r.params.set(resourcesBody, "memory_max", paramResources, fmt.Sprintf("Maximum memory in MB the %s task may use.", taskName), cty.NumberIntVal(res.memoryMax), taskName, "memory_max")
+	}
+	taskBody.AppendNewline()
+}
@@ -0,0 +1,49 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const resourcesDockerComposeYAML = `
+name: shop
+services:
+  web:
+    image: nginx:latest
+    ports:
+      - "8080:80"
+    environment:
+      NGINX_HOST: example.com
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 1gb
+        reservations:
+          memory: 256M
+  worker:
+    image: worker:1.0
+    mem_limit: 512m
+`
+
+func TestConvertToNomadHCL_Resources(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(resourcesDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["shop.nomad.hcl"]
+
+	webPattern := `task "web"[\s\S]*?resources\s*\{[\s\S]*?cpu\s*=\s*500\s*memory\s*=\s*256\s*memory_max\s*=\s*1024\s*\}`
+	if !regexp.MustCompile(webPattern).MatchString(hclOutput) {
+		t.Errorf("HCL output does not map web reservations and limits to cpu, memory and memory_max:\n%s", hclOutput)
+	}
+	workerPattern := `task "worker"[\s\S]*?resources\s*\{\s*memory\s*=\s*512\s*\}`
+	if !regexp.MustCompile(workerPattern).MatchString(hclOutput) {
+		t.Errorf("HCL output does not map worker mem_limit to memory:\n%s", hclOutput)
+	}
+}
@@ -0,0 +1,271 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// RestartOptions sets the Nomad restart policies the compose `restart` key
+// maps to. Zero fields take the defaults below.
+type RestartOptions struct {
+	// Attempts is the number of restarts within Interval, 3 by default.
+	// `always` and `unless-stopped` services wait for the interval to end once
+	// they are used up, `on-failure` services fail. `on-failure:<n>` sets the
+	// attempts of a service.
+	Attempts int
+	// Interval is the window restart attempts are counted in, 1m by default.
+	// It is raised to fit the attempts of `on-failure:<n>` services.
+	Interval time.Duration
+	// Delay is the time waited before a restart, Nomad's 15s by default.
+	Delay time.Duration
+}
+
+// Defaults of RestartOptions.
+const (
+	defaultRestartAttempts = 3
+	defaultRestartInterval = time.Minute
+	defaultRestartDelay    = 15 * time.Second
+)
+
+// Swarm's default delay between restarts of a task.
+const defaultSwarmRestartDelay = 5 * time.Second
+
+// Minimums Nomad enforces on restart and reschedule policies.
+const (
+	minRestartInterval    = 5 * time.Second
+	minRescheduleDelay    = 5 * time.Second
+	minRescheduleInterval = 15 * time.Second
+)
+
+// withDefaults returns the options with the zero fields set to their
+// defaults.
+func (o RestartOptions) withDefaults() RestartOptions {
+	if o.Attempts == 0 {
+		o.Attempts = defaultRestartAttempts
+	}
+	if o.Interval == 0 {
+		o.Interval = defaultRestartInterval
+	}
+	if o.Delay == 0 {
+		o.Delay = defaultRestartDelay
+	}
+	return o
+}
+
+// checkRestartOptions fails when the restart options are negative or their
+// attempts do not fit in the interval.
+func checkRestartOptions(opts RestartOptions) error {
+	if opts.Attempts < 0 || opts.Interval < 0 || opts.Delay < 0 {
+		return fmt.Errorf("restart attempts, interval and delay cannot be negative")
+	}
+	opts = opts.withDefaults()
+	if opts.Interval < minRestartInterval {
+		return fmt.Errorf("restart interval %s is less than Nomad's minimum of %s", opts.Interval, minRestartInterval)
+	}
+	if time.Duration(opts.Attempts)*opts.Delay > opts.Interval {
+		return fmt.Errorf("%d restart attempts with a delay of %s do not fit in the restart interval of %s", opts.Attempts, opts.Delay, opts.Interval)
+	}
+	return nil
+}
+
+// checkRestartPolicies fails when the deploy.restart_policy of a service has
+// an unknown condition, a negative max_attempts or a duration that cannot be
+// parsed.
+func checkRestartPolicies(dc *dockercompose.DockerCompose) error {
+	names := make([]string, 0, len(dc.Services))
+	for name := range dc.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		deploy := dc.Services[name].Deploy
+		if deploy == nil || deploy.RestartPolicy == nil {
+			continue
+		}
+		policy := deploy.RestartPolicy
+		switch policy.Condition {
+		case "", "none", "on-failure", "any":
+		default:
+			return fmt.Errorf("service '%s' has an unknown deploy.restart_policy.condition %q", name, policy.Condition)
+		}
+		if policy.MaxAttempts != nil && *policy.MaxAttempts < 0 {
+			return fmt.Errorf("service '%s' has a negative deploy.restart_policy.max_attempts %d", name, *policy.MaxAttempts)
+		}
+		for _, field := range [][2]string{{"delay", policy.Delay}, {"window", policy.Window}} {
+			if field[1] == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(field[1]); err != nil {
+				return fmt.Errorf("service '%s' has an invalid deploy.restart_policy.%s '%s': %w", name, field[0], field[1], err)
+			}
+		}
+	}
+	return nil
+}
+
+// restartPolicy is the restart block of a task.
+type restartPolicy struct {
+	attempts int
+	interval time.Duration // Zero keeps Nomad's default
+	delay    time.Duration
+	mode     string
+}
+
+// composeRestart maps the compose `restart` key to a restart policy using the
+// configured restart options.
+func (r *jobRenderer) composeRestart(restart string) (restartPolicy, bool) {
+	opts := r.opts.Restart.withDefaults()
+	switch {
+	case restart == "always" || restart == "unless-stopped":
+		return restartPolicy{attempts: opts.Attempts, interval: opts.Interval, delay: opts.Delay, mode: "delay"}, true
+	case restart == "on-failure" || strings.HasPrefix(restart, "on-failure:"):
+		attempts := opts.Attempts
+		if retries, err := strconv.Atoi(strings.TrimPrefix(restart, "on-failure:")); err == nil && retries > 0 {
+			attempts = retries
+		}
+		interval := max(opts.Interval, time.Duration(attempts)*opts.Delay)
+		return restartPolicy{attempts: attempts, interval: interval, delay: opts.Delay, mode: "fail"}, true
+	case restart == "no":
+		return restartPolicy{attempts: 0, delay: opts.Delay, mode: "fail"}, true
+	}
+	return restartPolicy{}, false
+}
+
+// swarmRestart maps a deploy.restart_policy to a restart policy. Unlimited
+// attempts restart the task at most once per delay; limited attempts are
+// counted within the window, or Nomad's default interval for the job type,
+// raised to fit them. Durations are validated by checkRestartPolicies before
+// rendering.
+func (r *jobRenderer) swarmRestart(policy *dockercompose.RestartPolicy) (restartPolicy, []string) {
+	var notes []string
+	delay := defaultSwarmRestartDelay
+	if policy.Delay != "" {
+		delay, _ = time.ParseDuration(policy.Delay)
+	}
+	window, _ := time.ParseDuration(policy.Window)
+
+	batchJob := r.job.jobType == jobTypeBatch || r.job.jobType == jobTypeSysbatch
+	switch policy.Condition {
+	case "none":
+		return restartPolicy{attempts: 0, delay: delay, mode: "fail"}, nil
+	case "on-failure":
+		if !batchJob {
+			notes = append(notes, "Nomad restarts the tasks of service and system jobs however they exit; condition on-failure also restarts tasks that exit successfully.")
+		}
+	default:
+		if batchJob {
+			notes = append(notes, fmt.Sprintf("Tasks of %s jobs are complete once they exit successfully; condition any does not restart them.", r.job.jobType))
+		}
+	}
+
+	if policy.MaxAttempts == nil || *policy.MaxAttempts == 0 {
+		return restartPolicy{attempts: 1, interval: max(delay, minRestartInterval), delay: delay, mode: "delay"}, notes
+	}
+	attempts := *policy.MaxAttempts
+	interval := window
+	if interval == 0 {
+		interval = 30 * time.Minute
+		if batchJob {
+			interval = 24 * time.Hour
+		}
+	}
+	interval = max(interval, time.Duration(attempts)*delay, minRestartInterval)
+	return restartPolicy{attempts: attempts, interval: interval, delay: delay, mode: "fail"}, notes
+}
+
+// addRestart writes the restart block of a task, from its deploy.restart_policy
+// or else its compose `restart` key.
+func (r *jobRenderer) addRestart(taskBody *hclwrite.Body, service dockercompose.Service) {
+	var policy restartPolicy
+	if service.Deploy != nil && service.Deploy.RestartPolicy != nil {
+		var notes []string
+		policy, notes = r.swarmRestart(service.Deploy.RestartPolicy)
+		if service.Restart != "" {
+			notes = append(notes, fmt.Sprintf("restart %q is overridden by deploy.restart_policy.", service.Restart))
+		}
+		for _, note := range notes {
+			taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+		}
+	} else {
+		var ok bool
+		if policy, ok = r.composeRestart(service.Restart); !ok {
+			return
+		}
+	}
+
+	restartBody := taskBody.AppendNewBlock("restart", nil).Body()
+	restartBody.SetAttributeValue("attempts", cty.NumberIntVal(int64(policy.attempts)))
+	if policy.interval > 0 {
+		restartBody.SetAttributeValue("interval", cty.StringVal(shortDuration(policy.interval)))
+	}
+	if policy.delay != defaultRestartDelay {
+		restartBody.SetAttributeValue("delay", cty.StringVal(shortDuration(policy.delay)))
+	}
+	restartBody.SetAttributeValue("mode", cty.StringVal(policy.mode))
+}
+
+// addReschedule writes the reschedule block of a group whose owning service
+// has a deploy.restart_policy. Swarm replaces the tasks of `condition: any`
+// services on any node, as many times as max_attempts allows within the
+// window, and never replaces those of `condition: none` services. System
+// jobs are not rescheduled.
+func (r *jobRenderer) addReschedule(groupBody *hclwrite.Body, group *groupPlan) {
+	deploy := r.dc.Services[group.name].Deploy
+	if deploy == nil || deploy.RestartPolicy == nil || isSystemJobType(r.job.jobType) {
+		return
+	}
+	policy := deploy.RestartPolicy
+	switch policy.Condition {
+	case "none":
+		rescheduleBody := groupBody.AppendNewBlock("reschedule", nil).Body()
+		rescheduleBody.SetAttributeValue("attempts", cty.NumberIntVal(0))
+		rescheduleBody.SetAttributeValue("unlimited", cty.False)
+		return
+	case "on-failure":
+		return
+	}
+
+	delay := defaultSwarmRestartDelay
+	if policy.Delay != "" {
+		delay, _ = time.ParseDuration(policy.Delay)
+	}
+	delay = max(delay, minRescheduleDelay)
+	rescheduleBody := groupBody.AppendNewBlock("reschedule", nil).Body()
+	if policy.MaxAttempts == nil || *policy.MaxAttempts == 0 {
+		rescheduleBody.SetAttributeValue("delay", cty.StringVal(shortDuration(delay)))
+		rescheduleBody.SetAttributeValue("delay_function", cty.StringVal("constant"))
+		rescheduleBody.SetAttributeValue("unlimited", cty.True)
+		return
+	}
+	attempts := *policy.MaxAttempts
+	window, _ := time.ParseDuration(policy.Window)
+	interval := max(window, time.Duration(attempts)*delay, minRescheduleInterval)
+	rescheduleBody.SetAttributeValue("attempts", cty.NumberIntVal(int64(attempts)))
+	rescheduleBody.SetAttributeValue("interval", cty.StringVal(shortDuration(interval)))
+	rescheduleBody.SetAttributeValue("delay", cty.StringVal(shortDuration(delay)))
+	rescheduleBody.SetAttributeValue("delay_function", cty.StringVal("constant"))
+	rescheduleBody.SetAttributeValue("unlimited", cty.False)
+}
+
+// shortDuration formats a duration without trailing zero units, e.g. "1m"
+// instead of "1m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const restartDockerComposeYAML = `
+services:
+  web:
+    image: web
+    restart: always
+    deploy:
+      restart_policy:
+        condition: any
+        delay: 10s
+        max_attempts: 3
+        window: 2m
+  worker:
+    image: worker
+    deploy:
+      restart_policy:
+        condition: on-failure
+  cron:
+    image: cron
+    x-nomad-oneshot: false
+    deploy:
+      restart_policy:
+        condition: none
+  retry:
+    image: retry
+    restart: on-failure:5
+`
+
+func TestConvertToNomadJobs_RestartPolicy(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(restartDockerComposeYAML, converter.Options{JobName: "restart"})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hcl := files["restart.nomad.hcl"]
+
+	checks := map[string]string{
+		"limited restart":    `group "web"[\s\S]*?restart\s*\{\s*attempts\s*=\s*3\s*interval\s*=\s*"2m"\s*delay\s*=\s*"10s"\s*mode\s*=\s*"fail"\s*\}`,
+		"limited reschedule": `group "web"\s*\{[\s\S]*?reschedule\s*\{\s*attempts\s*=\s*3\s*interval\s*=\s*"2m"\s*delay\s*=\s*"10s"\s*delay_function\s*=\s*"constant"\s*unlimited\s*=\s*false\s*\}`,
+		"overridden restart": `# restart "always" is overridden by deploy\.restart_policy\.`,
+		"unlimited restart":  `group "worker"[\s\S]*?restart\s*\{\s*attempts\s*=\s*1\s*interval\s*=\s*"5s"\s*delay\s*=\s*"5s"\s*mode\s*=\s*"delay"\s*\}`,
+		"on-failure note":    `# Nomad restarts the tasks of service and system jobs however they exit`,
+		"no restart":         `group "cron"[\s\S]*?restart\s*\{\s*attempts\s*=\s*0\s*delay\s*=\s*"5s"\s*mode\s*=\s*"fail"\s*\}`,
+		"no reschedule":      `group "cron"\s*\{[\s\S]*?reschedule\s*\{\s*attempts\s*=\s*0\s*unlimited\s*=\s*false\s*\}`,
+		"on-failure retries": `group "retry"[\s\S]*?restart\s*\{\s*attempts\s*=\s*5\s*interval\s*=\s*"1m15s"\s*mode\s*=\s*"fail"\s*\}`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hcl) {
+			t.Errorf("Expected %s in output:\n%s", name, hcl)
+		}
+	}
+	if regexp.MustCompile(`group "worker"\s*\{[^}]*reschedule`).MatchString(hcl) {
+		t.Errorf("on-failure services should keep Nomad's reschedule policy:\n%s", hcl)
+	}
+}
+
+func TestConvertToNomadJobs_RestartOptions(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{
+		JobName: "restart",
+		Restart: converter.RestartOptions{Attempts: 5, Interval: 10 * time.Minute, Delay: 30 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hcl := files["restart.nomad.hcl"]
+	pattern := `group "web"[\s\S]*?restart\s*\{\s*attempts\s*=\s*5\s*interval\s*=\s*"10m"\s*delay\s*=\s*"30s"\s*mode\s*=\s*"delay"\s*\}`
+	if !regexp.MustCompile(pattern).MatchString(hcl) {
+		t.Errorf("Expected the configured restart policy in output:\n%s", hcl)
+	}
+
+	_, err = converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{Restart: converter.RestartOptions{Attempts: 10, Interval: time.Minute}})
+	if err == nil || !strings.Contains(err.Error(), "do not fit in the restart interval") {
+		t.Errorf("Expected an error for attempts exceeding the interval, got %v", err)
+	}
+}
+
+func TestConvertToNomadJobs_RestartPolicyInvalid(t *testing.T) {
+	tests := map[string]string{
+		"services:\n  web:\n    image: web\n    deploy:\n      restart_policy:\n        condition: sometimes\n": `unknown deploy.restart_policy.condition "sometimes"`,
+		"services:\n  web:\n    image: web\n    deploy:\n      restart_policy:\n        window: later\n":        "invalid deploy.restart_policy.window 'later'",
+	}
+	for input, expected := range tests {
+		_, err := converter.ConvertToNomadJobs(input, converter.Options{})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/nomad/api"
+	"gopkg.in/yaml.v3"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// handledDockerConfig lists the docker and podman driver options carried over to compose.
+var handledDockerConfig = map[string]bool{
+	"image":      true,
+	"ports":      true,
+	"volumes":    true,
+	"command":    true,
+	"args":       true,
+	"entrypoint": true,
+}
+
+// ConvertNomadToCompose converts a Nomad job, given as HCL or as JSON (a job
+// or a `{"Job": {...}}` API payload), into a docker-compose YAML file. Every
+// docker or podman task becomes a compose service. Nomad features that compose cannot
+// represent are reported as warning diagnostics; parse failures are error
+// diagnostics and yield no output.
+func ConvertNomadToCompose(fileName string, input string) (string, hcl.Diagnostics) {
+	job, err := parseNomadJob(fileName, input)
+	if err != nil {
+		return "", parseDiagnostics(fileName, err)
+	}
+
+	c := &composeBuilder{
+		fileName: fileName,
+		dc: &dockercompose.DockerCompose{
+			Name:     strings.ToLower(stringValue(job.ID)),
+			Services: make(map[string]dockercompose.Service),
+		},
+	}
+	c.addJob(job)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.dc); err != nil {
+		return "", append(c.diags, jobDiagnostic(fileName, err.Error()))
+	}
+	return buf.String(), c.diags
+}
+
+// parseNomadJob parses a Nomad job from HCL, a JSON job or a JSON API payload.
+func parseNomadJob(fileName string, input string) (*api.Job, error) {
+	if !strings.HasPrefix(strings.TrimSpace(input), "{") {
+		return parseJobHCL(fileName, input, "")
+	}
+	var payload jobPayload
+	if err := json.Unmarshal([]byte(input), &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON job: %w", err)
+	}
+	if payload.Job != nil {
+		return payload.Job, nil
+	}
+	var job api.Job
+	if err := json.Unmarshal([]byte(input), &job); err != nil {
+		return nil, fmt.Errorf("invalid JSON job: %w", err)
+	}
+	return &job, nil
+}
+
+// composeBuilder accumulates the compose file converted from a Nomad job and
+// the diagnostics raised along the way.
+type composeBuilder struct {
+	fileName string
+	dc       *dockercompose.DockerCompose
+	diags    hcl.Diagnostics
+}
+
+// warn records a Nomad feature that cannot be represented in compose.
+func (c *composeBuilder) warn(format string, args ...any) {
+	c.diags = append(c.diags, &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  fmt.Sprintf("Unsupported in compose: %s", c.fileName),
+		Detail:   fmt.Sprintf(format, args...),
+	})
+}
+
+// addJob adds a compose service for every docker task of the job.
+func (c *composeBuilder) addJob(job *api.Job) {
+	if jobType := stringValue(job.Type); jobType != "" && jobType != "service" {
+		c.warn("Job type %q is not supported; tasks are converted as long-running services.", jobType)
+	}
+	if job.Periodic != nil {
+		c.warn("The periodic schedule of the job is not converted.")
+	}
+	if job.ParameterizedJob != nil {
+		c.warn("The parameterized job configuration is not converted.")
+	}
+	if len(job.Constraints) > 0 || len(job.Affinities) > 0 || len(job.Spreads) > 0 {
+		c.warn("Job constraints, affinities and spreads are not converted.")
+	}
+	if job.Update != nil {
+		c.warn("The job update strategy is not converted.")
+	}
+	if len(job.Meta) > 0 {
+		c.warn("The job meta is not converted.")
+	}
+
+	for _, group := range job.TaskGroups {
+		c.addGroup(group)
+	}
+}
+
+// addGroup adds a compose service for every docker task of the group. Tasks
+// sharing a bridge network join the network namespace of the group's main task.
+func (c *composeBuilder) addGroup(group *api.TaskGroup) {
+	groupName := stringValue(group.Name)
+	if len(group.Constraints) > 0 || len(group.Affinities) > 0 || len(group.Spreads) > 0 {
+		c.warn("Constraints, affinities and spreads of group %s are not converted.", groupName)
+	}
+	if group.Scaling != nil {
+		c.warn("The scaling policy of group %s is not converted.", groupName)
+	}
+	for _, service := range group.Services {
+		if service.Connect != nil {
+			c.warn("Consul Connect of service %s in group %s is not converted.", service.Name, groupName)
+		}
+	}
+	c.warnServices(group.Services, "group "+groupName)
+	if len(group.Meta) > 0 {
+		c.warn("The meta of group %s is not converted.", groupName)
+	}
+
+	var network *api.NetworkResource
+	if len(group.Networks) > 0 {
+		network = group.Networks[0]
+	}
+	if len(group.Networks) > 1 {
+		c.warn("Group %s declares several networks; only the first is converted.", groupName)
+	}
+	mode := ""
+	if network != nil {
+		mode = network.Mode
+	}
+
+	var tasks []*api.Task
+	for _, task := range group.Tasks {
+		if _, ok := taskDrivers[TaskDriver(task.Driver)]; !ok {
+			c.warn("Task %s in group %s uses the %q driver; only docker and podman tasks are converted.", task.Name, groupName, task.Driver)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	names := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		name := task.Name
+		if _, taken := c.dc.Services[name]; taken {
+			name = groupName + "-" + task.Name
+		}
+		names[task.Name] = name
+		c.dc.Services[name] = dockercompose.Service{}
+	}
+	owner := tasks[0]
+	for _, task := range tasks {
+		if task.Lifecycle == nil {
+			owner = task
+			break
+		}
+	}
+
+	for _, task := range tasks {
+		service := c.taskService(group, task)
+		switch {
+		case mode == "host":
+			service.NetworkMode = "host"
+		case mode == "bridge" && task == owner && network != nil:
+			service.Ports = c.networkPorts(groupName, network, nil)
+		case mode == "bridge":
+			service.NetworkMode = "service:" + names[owner.Name]
+		case network != nil:
+			service.Ports = c.networkPorts(groupName, network, stringItems(task.Config["ports"]))
+		}
+		if mode != "" && mode != "host" && mode != "bridge" {
+			c.warn("Network mode %q of group %s is not converted.", mode, groupName)
+		}
+		if group.Count != nil && *group.Count != 1 {
+			replicas := *group.Count
+			if service.Deploy == nil {
+				service.Deploy = &dockercompose.Deploy{}
+			}
+			service.Deploy.Replicas = &replicas
+		}
+		if dependsOn := c.lifecycleDependencies(groupName, task, tasks, names); len(dependsOn) > 0 {
+			service.DependsOn = dependsOn
+		}
+		c.dc.Services[names[task.Name]] = service
+	}
+}
+
+// taskService converts the task-level settings of a container task into a compose service.
+func (c *composeBuilder) taskService(group *api.TaskGroup, task *api.Task) dockercompose.Service {
+	groupName := stringValue(group.Name)
+	var service dockercompose.Service
+	service.Image, _ = task.Config["image"].(string)
+
+	var unhandled []string
+	for key := range task.Config {
+		if !handledDockerConfig[key] {
+			unhandled = append(unhandled, key)
+		}
+	}
+	if len(unhandled) > 0 {
+		sort.Strings(unhandled)
+		c.warn("Driver options %s of task %s are not converted.", strings.Join(unhandled, ", "), task.Name)
+	}
+
+	if entrypoint := stringItems(task.Config["entrypoint"]); len(entrypoint) > 0 {
+		service.Entrypoint = entrypoint
+	}
+	var command []string
+	if cmd, ok := task.Config["command"].(string); ok && cmd != "" {
+		command = append(command, cmd)
+	}
+	command = append(command, stringItems(task.Config["args"])...)
+	if len(command) > 0 {
+		service.Command = command
+	}
+
+	if len(task.Env) > 0 {
+		service.Environment = task.Env
+	}
+	service.Volumes = c.taskVolumes(group, task)
+
+	restart := task.RestartPolicy
+	if restart == nil {
+		restart = group.RestartPolicy
+	}
+	service.Restart = composeRestart(restart)
+
+	if task.Resources != nil {
+		service.Deploy = composeResources(task.Resources)
+	}
+
+	service.User = task.User
+	service.StopSignal = task.KillSignal
+	if task.KillTimeout != nil && *task.KillTimeout > 0 {
+		service.StopGracePeriod = shortDuration(*task.KillTimeout)
+	}
+
+	c.warnServices(task.Services, fmt.Sprintf("task %s in group %s", task.Name, groupName))
+	if task.LogConfig != nil {
+		c.warn("The logs block of task %s in group %s is not converted; compose rotates logs through the logging driver options.", task.Name, groupName)
+	}
+	if len(task.Meta) > 0 {
+		c.warn("The meta of task %s in group %s is not converted.", task.Name, groupName)
+	}
+
+	if len(task.Templates) > 0 {
+		c.warn("Templates of task %s in group %s are not converted.", task.Name, groupName)
+	}
+	if len(task.Artifacts) > 0 {
+		c.warn("Artifacts of task %s in group %s are not converted.", task.Name, groupName)
+	}
+	if task.Vault != nil {
+		c.warn("Vault integration of task %s in group %s is not converted.", task.Name, groupName)
+	}
+	if len(task.Constraints) > 0 || len(task.Affinities) > 0 {
+		c.warn("Constraints and affinities of task %s in group %s are not converted.", task.Name, groupName)
+	}
+	return service
+}
+
+// warnServices reports the service registrations of a group or task, which
+// compose has no equivalent for: services reach each other by name.
+func (c *composeBuilder) warnServices(services []*api.Service, owner string) {
+	if len(services) == 0 {
+		return
+	}
+	names := make([]string, 0, len(services))
+	checks := 0
+	for _, service := range services {
+		names = append(names, service.Name)
+		checks += len(service.Checks)
+	}
+	registrations := fmt.Sprintf("Services %s registered by %s", strings.Join(names, ", "), owner)
+	if checks > 0 {
+		registrations += " and their checks"
+	}
+	c.warn("%s are not converted; compose services reach each other by name.", registrations)
+}
+
+// taskVolumes converts docker bind volumes and volume mounts into compose volumes.
+// Group volumes are declared as top-level named volumes.
+func (c *composeBuilder) taskVolumes(group *api.TaskGroup, task *api.Task) []string {
+	volumes := stringItems(task.Config["volumes"])
+	for _, mount := range task.VolumeMounts {
+		name := stringValue(mount.Volume)
+		request, ok := group.Volumes[name]
+		if !ok {
+			c.warn("Volume mount of task %s references undefined volume %s.", task.Name, name)
+			continue
+		}
+		if request.Type == "csi" {
+			c.warn("CSI volume %s is converted to a local named volume.", name)
+		}
+		source := request.Source
+		if source == "" {
+			source = name
+		}
+		if c.dc.Volumes == nil {
+			c.dc.Volumes = make(map[string]any)
+		}
+		c.dc.Volumes[source] = map[string]any{}
+
+		volume := source + ":" + stringValue(mount.Destination)
+		if (mount.ReadOnly != nil && *mount.ReadOnly) || request.ReadOnly {
+			volume += ":ro"
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+// networkPorts converts the ports of a group network into compose port mappings.
+// Only the given labels are converted unless labels is nil.
+func (c *composeBuilder) networkPorts(groupName string, network *api.NetworkResource, labels []string) []string {
+	wanted := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		wanted[label] = true
+	}
+	var ports []string
+	for _, port := range network.ReservedPorts {
+		if labels != nil && !wanted[port.Label] {
+			continue
+		}
+		to := port.To
+		if to <= 0 {
+			to = port.Value
+		}
+		ports = append(ports, fmt.Sprintf("%d:%d", port.Value, to))
+	}
+	for _, port := range network.DynamicPorts {
+		if labels != nil && !wanted[port.Label] {
+			continue
+		}
+		if port.To <= 0 {
+			c.warn("Dynamic port %s of group %s is not mapped to a container port and cannot be converted.", port.Label, groupName)
+			continue
+		}
+		ports = append(ports, fmt.Sprintf("%d", port.To))
+	}
+	for _, port := range append(append([]api.Port{}, network.ReservedPorts...), network.DynamicPorts...) {
+		if port.HostNetwork != "" && (labels == nil || wanted[port.Label]) {
+			c.warn("Host network %s of port %s in group %s is not converted.", port.HostNetwork, port.Label, groupName)
+		}
+	}
+	return ports
+}
+
+// lifecycleDependencies converts task lifecycle hooks into compose depends_on
+// conditions: main tasks wait for prestart tasks, poststart tasks wait for the
+// main tasks.
+func (c *composeBuilder) lifecycleDependencies(groupName string, task *api.Task, tasks []*api.Task, names map[string]string) map[string]any {
+	dependsOn := make(map[string]any)
+	for _, other := range tasks {
+		if other == task {
+			continue
+		}
+		switch {
+		case task.Lifecycle == nil && other.Lifecycle != nil && other.Lifecycle.Hook == "prestart":
+			condition := "service_completed_successfully"
+			if other.Lifecycle.Sidecar {
+				condition = "service_started"
+			}
+			dependsOn[names[other.Name]] = map[string]any{"condition": condition}
+		case task.Lifecycle != nil && task.Lifecycle.Hook == "poststart" && other.Lifecycle == nil:
+			dependsOn[names[other.Name]] = map[string]any{"condition": "service_started"}
+		}
+	}
+	if task.Lifecycle != nil && task.Lifecycle.Hook == "poststop" {
+		c.warn("The poststop hook of task %s in group %s is not converted.", task.Name, groupName)
+	}
+	return dependsOn
+}
+
+// composeRestart converts a Nomad restart policy into a compose restart policy.
+func composeRestart(policy *api.RestartPolicy) string {
+	if policy == nil || policy.Mode == nil {
+		return ""
+	}
+	attempts := 0
+	if policy.Attempts != nil {
+		attempts = *policy.Attempts
+	}
+	switch {
+	case *policy.Mode == "delay":
+		return "always"
+	case attempts == 0:
+		return "no"
+	default:
+		return fmt.Sprintf("on-failure:%d", attempts)
+	}
+}
+
+// composeResources converts task resources into compose deploy resources. The
+// cpu and memory become reservations, memory_max the memory limit; memory is
+// the limit when no memory_max is set, as Nomad enforces it.
+func composeResources(resources *api.Resources) *dockercompose.Deploy {
+	var limits, reservations dockercompose.ResourceSpec
+	if resources.CPU != nil && *resources.CPU > 0 {
+		reservations.Cpus = fmt.Sprintf("%g", float64(*resources.CPU)/mhzPerCPU)
+	}
+	if resources.MemoryMB != nil && *resources.MemoryMB > 0 {
+		memory := fmt.Sprintf("%dM", *resources.MemoryMB)
+		if resources.MemoryMaxMB != nil && *resources.MemoryMaxMB > *resources.MemoryMB {
+			reservations.Memory = memory
+			limits.Memory = fmt.Sprintf("%dM", *resources.MemoryMaxMB)
+		} else {
+			limits.Memory = memory
+		}
+	}
+
+	res := &dockercompose.Resources{}
+	if limits != (dockercompose.ResourceSpec{}) {
+		res.Limits = &limits
+	}
+	if reservations != (dockercompose.ResourceSpec{}) {
+		res.Reservations = &reservations
+	}
+	if res.Limits == nil && res.Reservations == nil {
+		return nil
+	}
+	return &dockercompose.Deploy{Resources: res}
+}
+
+// stringItems returns the string items of a list decoded from a driver config.
+func stringItems(raw any) []string {
+	var items []string
+	switch typed := raw.(type) {
+	case []any:
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+	case []string:
+		items = typed
+	}
+	return items
+}
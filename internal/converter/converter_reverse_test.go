@@ -0,0 +1,214 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+const unsupportedNomadJobHCL = `
+job "batch" {
+  type = "batch"
+
+  constraint {
+    attribute = "${attr.kernel.name}"
+    value     = "linux"
+  }
+
+  group "work" {
+    network {
+      port "metrics" {}
+    }
+
+    task "crunch" {
+      driver = "docker"
+
+      config {
+        image      = "cruncher:2"
+        ports      = ["metrics"]
+        privileged = true
+      }
+
+      template {
+        data        = "key = value"
+        destination = "local/app.conf"
+      }
+    }
+
+    task "script" {
+      driver = "exec"
+
+      config {
+        command = "/bin/true"
+      }
+    }
+  }
+}
+`
+
+// parseCompose decodes a converted compose file.
+func parseCompose(t *testing.T, yamlOutput string) dockercompose.DockerCompose {
+	t.Helper()
+	var dc dockercompose.DockerCompose
+	if err := yaml.Unmarshal([]byte(yamlOutput), &dc); err != nil {
+		t.Fatalf("Converted compose file is not valid YAML: %v\n%s", err, yamlOutput)
+	}
+	return dc
+}
+
+func TestConvertNomadToCompose_RoundTrip(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(sampleDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	yamlOutput, diags := converter.ConvertNomadToCompose("job.nomad.hcl", files["my-docker-compose-job.nomad.hcl"])
+	// Only the service registrations of the groups have no compose equivalent.
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagWarning || !strings.Contains(diag.Detail, "registered by group") {
+			t.Fatalf("Unexpected diagnostic: %v", diag)
+		}
+	}
+	dc := parseCompose(t, yamlOutput)
+
+	web := dc.Services["web"]
+	if web.Image != "nginx:latest" || strings.Join(web.Ports, ",") != "80:80,443:443" {
+		t.Errorf("Unexpected web image or ports:\n%s", yamlOutput)
+	}
+	if strings.Join(web.Volumes, ",") != "./nginx.conf:/etc/nginx/nginx.conf:ro,logs:/var/log/nginx" {
+		t.Errorf("Unexpected web volumes %v:\n%s", web.Volumes, yamlOutput)
+	}
+	if _, ok := dc.Volumes["logs"]; !ok {
+		t.Errorf("Named volume logs is not declared:\n%s", yamlOutput)
+	}
+	if web.Restart != "always" || web.Deploy == nil || web.Deploy.Replicas == nil || *web.Deploy.Replicas != 2 {
+		t.Errorf("Unexpected web restart or replicas:\n%s", yamlOutput)
+	}
+
+	api := dc.Services["api"]
+	if strings.Join(api.Ports, ",") != "3000" || api.Restart != "on-failure:3" {
+		t.Errorf("Unexpected api ports or restart:\n%s", yamlOutput)
+	}
+	if !strings.Contains(yamlOutput, "- /app/start\n      - --port\n") {
+		t.Errorf("Command and args are not converted to the compose command:\n%s", yamlOutput)
+	}
+	if !strings.Contains(yamlOutput, `PUID: "1000"`) {
+		t.Errorf("Env is not converted to the compose environment:\n%s", yamlOutput)
+	}
+}
+
+func TestConvertNomadToCompose_RoundTripTaskSettings(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(`
+name: shop
+services:
+  web:
+    image: nginx:latest
+    ports:
+      - "8080:80"
+    user: "1000:1000"
+    stop_signal: SIGQUIT
+    stop_grace_period: 1m30s
+    logging:
+      driver: json-file
+      options:
+        max-size: 10m
+`, converter.Options{Provenance: true})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	yamlOutput, diags := converter.ConvertNomadToCompose("shop.nomad.hcl", files["shop.nomad.hcl"])
+	if diags.HasErrors() {
+		t.Fatalf("ConvertNomadToCompose failed: %v", diags)
+	}
+	web := parseCompose(t, yamlOutput).Services["web"]
+	if web.User != "1000:1000" || web.StopSignal != "SIGQUIT" || web.StopGracePeriod != "1m30s" {
+		t.Errorf("Unexpected web user, stop_signal or stop_grace_period:\n%s", yamlOutput)
+	}
+
+	for _, want := range []string{"Services web registered by group web", "logs block of task web", "job meta"} {
+		found := false
+		for _, diag := range diags {
+			found = found || diag.Severity == hcl.DiagWarning && strings.Contains(diag.Detail, want)
+		}
+		if !found {
+			t.Errorf("Expected a warning mentioning %q, got %v", want, diags)
+		}
+	}
+}
+
+func TestConvertNomadToCompose_Sidecars(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(sidecarDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	for fileName, hclOutput := range files {
+		yamlOutput, diags := converter.ConvertNomadToCompose(fileName, hclOutput)
+		if diags.HasErrors() {
+			t.Fatalf("ConvertNomadToCompose failed: %v", diags)
+		}
+		dc := parseCompose(t, yamlOutput)
+		if dc.Services["qbittorrent"].NetworkMode != "service:vpn" {
+			t.Errorf("Tasks sharing the group network should join the main task's namespace:\n%s", yamlOutput)
+		}
+		if len(dc.Services["vpn"].Ports) == 0 {
+			t.Errorf("The group ports should be published by the main task:\n%s", yamlOutput)
+		}
+	}
+}
+
+func TestConvertNomadToCompose_JSON(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(resourcesDockerComposeYAML, converter.Options{Format: converter.FormatJSON})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	yamlOutput, diags := converter.ConvertNomadToCompose("shop.json", files["shop.json"])
+	if diags.HasErrors() {
+		t.Fatalf("ConvertNomadToCompose failed: %v", diags)
+	}
+	dc := parseCompose(t, yamlOutput)
+	web := dc.Services["web"]
+	if web.Image != "nginx:latest" || strings.Join(web.Ports, ",") != "8080:80" {
+		t.Errorf("Unexpected web service converted from JSON:\n%s", yamlOutput)
+	}
+	if web.Deploy == nil || web.Deploy.Resources == nil || web.Deploy.Resources.Limits == nil || web.Deploy.Resources.Limits.Memory != "1024M" {
+		t.Errorf("memory_max is not converted to the memory limit:\n%s", yamlOutput)
+	}
+}
+
+func TestConvertNomadToCompose_Diagnostics(t *testing.T) {
+	yamlOutput, diags := converter.ConvertNomadToCompose("batch.nomad.hcl", unsupportedNomadJobHCL)
+	if diags.HasErrors() {
+		t.Fatalf("ConvertNomadToCompose failed: %v", diags)
+	}
+	for _, want := range []string{`Job type "batch"`, "Job constraints", `"exec" driver`, "privileged", "Templates of task crunch", "Dynamic port metrics"} {
+		found := false
+		for _, diag := range diags {
+			found = found || strings.Contains(diag.Detail, want)
+		}
+		if !found {
+			t.Errorf("Expected a diagnostic mentioning %q, got %v", want, diags)
+		}
+	}
+	dc := parseCompose(t, yamlOutput)
+	if _, ok := dc.Services["script"]; ok {
+		t.Errorf("Non-docker tasks should not be converted:\n%s", yamlOutput)
+	}
+	if dc.Services["crunch"].Image != "cruncher:2" {
+		t.Errorf("Docker task crunch is not converted:\n%s", yamlOutput)
+	}
+}
+
+func TestConvertNomadToCompose_Invalid(t *testing.T) {
+	if _, diags := converter.ConvertNomadToCompose("broken.nomad.hcl", "job \"x\" {\n  group \"g\" {\n"); !diags.HasErrors() {
+		t.Errorf("Expected error diagnostics for invalid HCL")
+	}
+	if _, diags := converter.ConvertNomadToCompose("broken.json", `{"Job": `); !diags.HasErrors() {
+		t.Errorf("Expected error diagnostics for invalid JSON")
+	}
+}
@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// defaultAllowedCaps are the capabilities the docker and podman drivers allow
+// when the client plugin config does not set allow_caps.
+var defaultAllowedCaps = map[string]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// deviceMapping is a host device exposed to a container.
+type deviceMapping struct {
+	host        string
+	container   string
+	permissions string
+}
+
+// taskSecurity holds the security related settings of a compose service.
+type taskSecurity struct {
+	privileged  bool
+	capAdd      []string
+	capDrop     []string
+	devices     []deviceMapping
+	securityOpt []string
+	readOnly    bool
+	groupAdd    []string
+	usernsMode  string
+}
+
+// serviceSecurity collects the security settings of a compose service. Device
+// entries that cannot be parsed are returned as notes.
+func serviceSecurity(service dockercompose.Service) (taskSecurity, []string) {
+	sec := taskSecurity{
+		privileged:  service.Privileged,
+		capAdd:      service.CapAdd,
+		capDrop:     service.CapDrop,
+		securityOpt: service.SecurityOpt,
+		readOnly:    service.ReadOnly,
+		usernsMode:  service.UsernsMode,
+	}
+	for _, group := range service.GroupAdd {
+		sec.groupAdd = append(sec.groupAdd, fmt.Sprint(group))
+	}
+
+	var notes []string
+	for _, raw := range service.Devices {
+		device, err := parseDevice(raw)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Skipping device: %v.", err))
+			continue
+		}
+		sec.devices = append(sec.devices, device)
+	}
+	return sec, notes
+}
+
+// parseDevice parses a compose device in its short ("/dev/a:/dev/b:rwm") or
+// long (source/target/permissions) form.
+func parseDevice(raw any) (deviceMapping, error) {
+	var device deviceMapping
+	switch typed := raw.(type) {
+	case string:
+		parts := strings.Split(typed, ":")
+		if len(parts) > 3 || !strings.HasPrefix(parts[0], "/") {
+			return device, fmt.Errorf("only host device paths can be mapped, got '%s'", typed)
+		}
+		device.host = parts[0]
+		if len(parts) > 1 {
+			device.container = parts[1]
+		}
+		if len(parts) > 2 {
+			device.permissions = parts[2]
+		}
+	case map[string]any:
+		device.host, _ = typed["source"].(string)
+		device.container, _ = typed["target"].(string)
+		device.permissions, _ = typed["permissions"].(string)
+		if !strings.HasPrefix(device.host, "/") {
+			return device, fmt.Errorf("only host device paths can be mapped, got '%v'", typed)
+		}
+	default:
+		return device, fmt.Errorf("invalid device '%v'", raw)
+	}
+	if device.container == "" {
+		device.container = device.host
+	}
+	if device.permissions == "" {
+		device.permissions = "rwm"
+	}
+	return device, nil
+}
+
+// isEmpty reports whether no security setting is set.
+func (s taskSecurity) isEmpty() bool {
+	return !s.privileged && !s.readOnly && s.usernsMode == "" && len(s.capAdd) == 0 && len(s.capDrop) == 0 &&
+		len(s.devices) == 0 && len(s.securityOpt) == 0 && len(s.groupAdd) == 0
+}
+
+// restrictedCaps returns the added capabilities outside the drivers' default
+// allow_caps list, in upper case.
+func (s taskSecurity) restrictedCaps() []string {
+	var caps []string
+	for _, capability := range s.capAdd {
+		name := strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+		if !defaultAllowedCaps[name] {
+			caps = append(caps, name)
+		}
+	}
+	sort.Strings(caps)
+	return caps
+}
+
+// addSecurity writes the security settings of a service to the task config.
+// Settings that need client plugin configuration are noted in comments.
+func (r *jobRenderer) addSecurity(configBody *hclwrite.Body, service dockercompose.Service) {
+	sec, notes := serviceSecurity(service)
+	for _, note := range notes {
+		configBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	if !sec.isEmpty() {
+		r.driver.setSecurity(configBody, sec)
+	}
+}
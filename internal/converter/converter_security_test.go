@@ -0,0 +1,82 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const securityDockerComposeYAML = `
+services:
+  vpn:
+    image: qmcgaw/gluetun
+    user: "1000:1000"
+    privileged: true
+    cap_add: [NET_ADMIN, chown]
+    cap_drop: [ALL]
+    devices:
+      - /dev/net/tun
+      - /dev/sda:/dev/xvda:r
+      - source: /dev/fuse
+        target: /dev/fuse
+      - vendor.com/gpu=all
+    security_opt: ["no-new-privileges:true"]
+    read_only: true
+    group_add: [video, 44]
+    userns_mode: host
+`
+
+func TestConvertToNomadHCL_Security(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(securityDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"user":             `driver\s*=\s*"docker"\s*user\s*=\s*"1000:1000"`,
+		"privileged":       `privileged\s*=\s*true`,
+		"cap_add":          `cap_add\s*=\s*\["NET_ADMIN", "chown"\]`,
+		"cap_drop":         `cap_drop\s*=\s*\["ALL"\]`,
+		"short device":     `cgroup_permissions\s*=\s*"rwm"\s*container_path\s*=\s*"/dev/net/tun"\s*host_path\s*=\s*"/dev/net/tun"`,
+		"mapped device":    `cgroup_permissions\s*=\s*"r"\s*container_path\s*=\s*"/dev/xvda"\s*host_path\s*=\s*"/dev/sda"`,
+		"long device":      `container_path\s*=\s*"/dev/fuse"`,
+		"security_opt":     `security_opt\s*=\s*\["no-new-privileges:true"\]`,
+		"readonly_rootfs":  `readonly_rootfs\s*=\s*true`,
+		"group_add":        `group_add\s*=\s*\["video", "44"\]`,
+		"userns_mode":      `userns_mode\s*=\s*"host"`,
+		"privileged note":  `# .*allow_privileged = true`,
+		"allow_caps note":  `# Capabilities NET_ADMIN must be listed in allow_caps`,
+		"skipped CDI note": `# Skipping device: .*vendor\.com/gpu=all`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Contains(hclOutput, "CHOWN must be") {
+		t.Errorf("Capabilities allowed by default should not be noted:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_PodmanSecurity(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(securityDockerComposeYAML, converter.Options{Driver: converter.DriverPodman})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	checks := map[string]string{
+		"devices":   `devices\s*=\s*\["/dev/net/tun:/dev/net/tun:rwm", "/dev/sda:/dev/xvda:r", "/dev/fuse:/dev/fuse:rwm"\]`,
+		"userns":    `userns\s*=\s*"host"`,
+		"group_add": `# The podman driver does not support group_add`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s for podman:\n%s", name, hclOutput)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// defaultMaxKillTimeout is the default max_kill_timeout of Nomad clients.
+const defaultMaxKillTimeout = 30 * time.Second
+
+// signalNames maps the Linux signal numbers a compose stop_signal may use to
+// the signal names Nomad expects.
+var signalNames = map[string]string{
+	"1": "SIGHUP", "2": "SIGINT", "3": "SIGQUIT", "4": "SIGILL", "5": "SIGTRAP",
+	"6": "SIGABRT", "7": "SIGBUS", "8": "SIGFPE", "9": "SIGKILL", "10": "SIGUSR1",
+	"11": "SIGSEGV", "12": "SIGUSR2", "13": "SIGPIPE", "14": "SIGALRM", "15": "SIGTERM",
+	"28": "SIGWINCH",
+}
+
+// killSignal converts a compose stop_signal such as "QUIT", "SIGQUIT" or "3"
+// into the signal name Nomad expects. It reports false for unknown signal
+// numbers.
+func killSignal(stopSignal string) (string, bool) {
+	signal := strings.ToUpper(strings.TrimSpace(stopSignal))
+	if signal != "" && strings.Trim(signal, "0123456789") == "" {
+		name, ok := signalNames[strings.TrimLeft(signal, "0")]
+		return name, ok
+	}
+	if signal == "" || strings.HasPrefix(signal, "SIG") {
+		return signal, true
+	}
+	return "SIG" + signal, true
+}
+
+// checkStopSignals fails when the stop_signal of a service is a signal number
+// without a known signal name, as Nomad only accepts signal names.
+func checkStopSignals(dc *dockercompose.DockerCompose) error {
+	names := make([]string, 0, len(dc.Services))
+	for name := range dc.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		stopSignal := dc.Services[name].StopSignal
+		if _, ok := killSignal(stopSignal); !ok {
+			return fmt.Errorf("service '%s' has an unknown stop_signal number %s", name, stopSignal)
+		}
+	}
+	return nil
+}
+
+// checkKillTimeouts fails when the stop_grace_period of a service cannot be
+// parsed or exceeds the given max_kill_timeout. A zero max_kill_timeout skips
+// the limit check, leaving longer grace periods to be noted on the task.
+func checkKillTimeouts(dc *dockercompose.DockerCompose, maxKillTimeout time.Duration) error {
+	names := make([]string, 0, len(dc.Services))
+	for name := range dc.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		gracePeriod := dc.Services[name].StopGracePeriod
+		if gracePeriod == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(gracePeriod)
+		if err != nil {
+			return fmt.Errorf("service '%s' has an invalid stop_grace_period '%s': %w", name, gracePeriod, err)
+		}
+		if maxKillTimeout > 0 && timeout > maxKillTimeout {
+			return fmt.Errorf("stop_grace_period %s of service '%s' exceeds the client max_kill_timeout of %s", shortDuration(timeout), name, shortDuration(maxKillTimeout))
+		}
+	}
+	return nil
+}
+
+// addShutdown writes the kill signal and timeout of a service to the task.
+// Signals and grace periods are validated by checkStopSignals and
+// checkKillTimeouts before rendering.
+func (r *jobRenderer) addShutdown(taskBody *hclwrite.Body, service dockercompose.Service) {
+	if service.StopSignal != "" {
+		signal, _ := killSignal(service.StopSignal)
+		taskBody.SetAttributeValue("kill_signal", cty.StringVal(signal))
+	}
+	if service.StopGracePeriod == "" {
+		return
+	}
+	timeout, err := time.ParseDuration(service.StopGracePeriod)
+	if err != nil {
+		return
+	}
+	if r.opts.MaxKillTimeout == 0 && timeout > defaultMaxKillTimeout {
+		taskBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf("kill_timeout exceeds Nomad's default max_kill_timeout of %s; raise max_kill_timeout on the clients or the timeout is capped.", defaultMaxKillTimeout)))
+	}
+	taskBody.SetAttributeValue("kill_timeout", cty.StringVal(shortDuration(timeout)))
+}
@@ -0,0 +1,91 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const shutdownDockerComposeYAML = `
+services:
+  web:
+    image: nginx
+    stop_signal: QUIT
+    stop_grace_period: 1m30s
+  db:
+    image: postgres
+    stop_signal: SIGINT
+    stop_grace_period: 10s
+  cache:
+    image: redis
+    stop_signal: "9"
+    stop_grace_period: 1m
+`
+
+func TestConvertToNomadHCL_Shutdown(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(shutdownDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"web kill_signal":    `task "web"[\s\S]*?kill_signal\s*=\s*"SIGQUIT"`,
+		"web kill_timeout":   `task "web"[\s\S]*?kill_timeout\s*=\s*"1m30s"`,
+		"db kill_signal":     `task "db"[\s\S]*?kill_signal\s*=\s*"SIGINT"`,
+		"db kill_timeout":    `task "db"[\s\S]*?kill_timeout\s*=\s*"10s"`,
+		"cache kill_signal":  `task "cache"[\s\S]*?kill_signal\s*=\s*"SIGKILL"`,
+		"cache kill_timeout": `task "cache"[\s\S]*?kill_timeout\s*=\s*"1m"\n`,
+		"default note":       `# kill_timeout exceeds Nomad's default max_kill_timeout of 30s`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_MaxKillTimeout(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(shutdownDockerComposeYAML, converter.Options{MaxKillTimeout: 2 * time.Minute})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if hclOutput := files["my-docker-compose-job.nomad.hcl"]; strings.Contains(hclOutput, "max_kill_timeout") {
+		t.Errorf("Grace periods within the configured max_kill_timeout should not be noted:\n%s", hclOutput)
+	}
+
+	_, err = converter.ConvertToNomadJobs(shutdownDockerComposeYAML, converter.Options{MaxKillTimeout: time.Minute})
+	if err == nil || !strings.Contains(err.Error(), "stop_grace_period 1m30s of service 'web' exceeds the client max_kill_timeout of 1m") {
+		t.Errorf("Expected a max_kill_timeout error, got %v", err)
+	}
+}
+
+func TestConvertToNomadJobs_InvalidStopGracePeriod(t *testing.T) {
+	yaml := `
+services:
+  web:
+    image: nginx
+    stop_grace_period: soon
+`
+	_, err := converter.ConvertToNomadJobs(yaml, converter.Options{})
+	if err == nil || !strings.Contains(err.Error(), "invalid stop_grace_period 'soon'") {
+		t.Errorf("Expected an invalid stop_grace_period error, got %v", err)
+	}
+}
+
+func TestConvertToNomadJobs_UnknownStopSignalNumber(t *testing.T) {
+	yaml := `
+services:
+  web:
+    image: nginx
+    stop_signal: "99"
+`
+	_, err := converter.ConvertToNomadJobs(yaml, converter.Options{})
+	if err == nil || !strings.Contains(err.Error(), "unknown stop_signal number 99") {
+		t.Errorf("Expected an unknown stop_signal error, got %v", err)
+	}
+}
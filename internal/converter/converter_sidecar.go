@@ -0,0 +1,82 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// groupPlan describes a Nomad group: the service owning the network namespace
+// and the services folded into it because they share that namespace.
+type groupPlan struct {
+	name     string
+	sidecars []string
+	prestart []string // One-shot services run before the group's tasks
+	notes    []string // Problems found while grouping, rendered as comments
+}
+
+// members returns the owning service followed by its sidecars.
+func (g *groupPlan) members() []string {
+	return append([]string{g.name}, g.sidecars...)
+}
+
+// sharedNetworkTarget returns the service or container named by a
+// `network_mode: service:<name>` or `network_mode: container:<name>` entry.
+func sharedNetworkTarget(service dockercompose.Service) (string, bool) {
+	for _, prefix := range []string{"service:", "container:"} {
+		if strings.HasPrefix(service.NetworkMode, prefix) {
+			return strings.TrimPrefix(service.NetworkMode, prefix), true
+		}
+	}
+	return "", false
+}
+
+// networkOwner follows `network_mode` references from the named service to the
+// service that owns the shared network namespace, through any number of hops.
+// A service whose own reference cannot be resolved owns its namespace and a
+// note explains why; services referring to it share that namespace, as in
+// compose.
+func networkOwner(dc *dockercompose.DockerCompose, serviceName string) (string, string) {
+	visited := map[string]bool{serviceName: true}
+	current := serviceName
+	for {
+		target, ok := sharedNetworkTarget(dc.Services[current])
+		if !ok {
+			return current, ""
+		}
+		targetService, found := serviceByNameOrContainer(dc, target)
+		if !found {
+			if current != serviceName {
+				// The note is attached to current when its own group is planned.
+				return current, ""
+			}
+			return serviceName, fmt.Sprintf("network_mode '%s' of service '%s' refers to a container outside of this compose file. Its network namespace cannot be shared.", dc.Services[current].NetworkMode, current)
+		}
+		if visited[targetService] {
+			return serviceName, fmt.Sprintf("network_mode of service '%s' forms a cycle. Its network namespace cannot be shared.", serviceName)
+		}
+		visited[targetService] = true
+		current = targetService
+	}
+}
+
+// serviceByNameOrContainer resolves a service name or `container_name` to the service name.
+func serviceByNameOrContainer(dc *dockercompose.DockerCompose, name string) (string, bool) {
+	if _, ok := dc.Services[name]; ok {
+		return name, true
+	}
+	for serviceName, service := range dc.Services {
+		if service.ContainerName == name {
+			return serviceName, true
+		}
+	}
+	return "", false
+}
+
+// sidecarLifecycle returns the lifecycle of a task folded into another
+// service's group. Compose starts the namespace owner first, so folded tasks
+// run after it; long-running ones are kept alive as sidecars.
+func sidecarLifecycle(dc *dockercompose.DockerCompose, serviceName string) *taskLifecycle {
+	return &taskLifecycle{hook: "poststart", sidecar: oneShotReason(dc, serviceName) == ""}
+}
@@ -0,0 +1,115 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const sidecarDockerComposeYAML = `
+services:
+  vpn:
+    image: qmcgaw/gluetun
+    ports:
+      - "8080:8080"
+  qbittorrent:
+    image: linuxserver/qbittorrent
+    container_name: qbit
+    network_mode: "service:vpn"
+    ports:
+      - "6881:6881"
+    x-nomad-job: downloads
+  seeder:
+    image: busybox
+    network_mode: "container:qbit"
+    restart: "no"
+  lonely:
+    image: busybox
+    network_mode: "container:elsewhere"
+`
+
+func TestConvertToNomadHCL_NetworkModeService(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(sidecarDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	for _, group := range []string{"qbittorrent", "seeder"} {
+		if strings.Contains(hclOutput, `group "`+group+`"`) {
+			t.Errorf("Expected service '%s' to be folded into the vpn group", group)
+		}
+	}
+	qbitPattern := `group "vpn"[\s\S]*?task "qbittorrent"\s*\{\s*driver\s*=\s*"docker"\s*lifecycle\s*\{\s*hook\s*=\s*"poststart"\s*sidecar\s*=\s*true\s*\}`
+	if !regexp.MustCompile(qbitPattern).MatchString(hclOutput) {
+		t.Errorf("HCL output does not run qbittorrent as a poststart sidecar of the vpn group:\n%s", hclOutput)
+	}
+	// container:<name> is resolved through container_name and followed to the namespace owner.
+	seederPattern := `group "vpn"[\s\S]*?task "seeder"\s*\{\s*driver\s*=\s*"docker"\s*lifecycle\s*\{\s*hook\s*=\s*"poststart"\s*\}`
+	if !regexp.MustCompile(seederPattern).MatchString(hclOutput) {
+		t.Errorf("HCL output does not run the one-shot seeder as a poststart task of the vpn group:\n%s", hclOutput)
+	}
+	networkPattern := `group "vpn"[\s\S]*?network\s*\{\s*mode\s*=\s*"bridge"\s*port "port_8080"\s*\{\s*static\s*=\s*8080\s*\}\s*port "port_6881"\s*\{\s*static\s*=\s*6881\s*\}\s*\}`
+	if !regexp.MustCompile(networkPattern).MatchString(hclOutput) {
+		t.Errorf("HCL output does not move all port mappings onto the shared bridge network:\n%s", hclOutput)
+	}
+	if strings.Contains(hclOutput, "ports =") {
+		t.Errorf("Expected tasks sharing a network namespace not to map ports themselves")
+	}
+	if !strings.Contains(hclOutput, `group "lonely"`) || !strings.Contains(hclOutput, "refers to a container outside of this compose file") {
+		t.Errorf("Expected unresolved network_mode target to keep its own group with a note")
+	}
+}
+
+func TestConvertToNomadJobs_SidecarFollowsOwnerJob(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(sidecarDockerComposeYAML, converter.Options{Split: converter.SplitLabel})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if _, ok := files["downloads.nomad.hcl"]; ok {
+		t.Errorf("Expected qbittorrent to stay with its network namespace owner rather than its own job")
+	}
+}
+
+func TestConvertToNomadHCL_NetworkModeChain(t *testing.T) {
+	yaml := `
+services:
+  app:
+    image: busybox
+    network_mode: "service:proxy"
+  proxy:
+    image: busybox
+    network_mode: "service:vpn"
+  vpn:
+    image: qmcgaw/gluetun
+  client:
+    image: busybox
+    network_mode: "service:tunnel"
+  tunnel:
+    image: busybox
+    network_mode: "container:elsewhere"
+`
+	hclOutput, err := converter.ConvertToNomadHCL(yaml)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	if !regexp.MustCompile(`group "vpn"[\s\S]*?task "app"[\s\S]*?task "proxy"`).MatchString(hclOutput) {
+		t.Errorf("Expected a two-hop chain to be folded into the final owner's group:\n%s", hclOutput)
+	}
+	for _, group := range []string{"app", "proxy", "client"} {
+		if strings.Contains(hclOutput, `group "`+group+`"`) {
+			t.Errorf("Expected service '%s' to be folded into the group of its chain's owner", group)
+		}
+	}
+	tunnelPattern := `group "tunnel"\s*\{[^}]*?# network_mode 'container:elsewhere' of service 'tunnel' refers to a container outside of this compose file[\s\S]*?task "tunnel"[\s\S]*?task "client"`
+	if !regexp.MustCompile(tunnelPattern).MatchString(hclOutput) {
+		t.Errorf("Expected a chain through an unresolvable hop to share the namespace of that hop, noted there:\n%s", hclOutput)
+	}
+	if strings.Count(hclOutput, "container:elsewhere") != 1 {
+		t.Errorf("Expected the unresolvable hop to be noted once:\n%s", hclOutput)
+	}
+}
@@ -0,0 +1,181 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+)
+
+// SplitMode selects how compose services are distributed across Nomad jobs.
+type SplitMode string
+
+const (
+	// SplitNone places every service in a single job.
+	SplitNone SplitMode = ""
+	// SplitService emits one job per compose service.
+	SplitService SplitMode = "service"
+	// SplitLabel emits one job per `x-nomad-job` value. Services without the
+	// key stay in the default job. Services sharing a network namespace
+	// always follow the job of the namespace owner.
+	SplitLabel SplitMode = "label"
+)
+
+// jobLabel is the service label (or extension key) naming the job a service belongs to.
+const jobLabel = "x-nomad-job"
+
+// jobPlan describes a Nomad job to generate and the groups it contains.
+type jobPlan struct {
+	name     string
+	jobType  string
+	groups   []*groupPlan
+	periodic *periodicPlan // Schedule of a periodic job, nil otherwise
+}
+
+// fileName returns the name of the file the job is written to.
+func (j *jobPlan) fileName() string {
+	return j.name + ".nomad.hcl"
+}
+
+// planJobs groups the compose services by shared network namespace and
+// distributes the groups across jobs according to the split mode. Jobs and
+// groups are sorted by name for stable output.
+func planJobs(dc *dockercompose.DockerCompose, opts Options) ([]*jobPlan, error) {
+	defaultName := defaultJobNameFor(dc, opts)
+	if err := checkJobID(defaultName); err != nil {
+		return nil, fmt.Errorf("invalid job name %q: %w", defaultName, err)
+	}
+
+	serviceNames := make([]string, 0, len(dc.Services))
+	for serviceName := range dc.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	groupsByName := make(map[string]*groupPlan)
+	var groups []*groupPlan
+	groupFor := func(name string) *groupPlan {
+		group, ok := groupsByName[name]
+		if !ok {
+			group = &groupPlan{name: name}
+			groupsByName[name] = group
+			groups = append(groups, group)
+		}
+		return group
+	}
+	for _, serviceName := range serviceNames {
+		owner, note := networkOwner(dc, serviceName)
+		group := groupFor(owner)
+		if owner != serviceName {
+			group.sidecars = append(group.sidecars, serviceName)
+		}
+		if note != "" {
+			group.notes = append(group.notes, note)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	groups = planPrestart(dc, groups)
+
+	// A Nomad job has a single type, so groups of different types planned
+	// for the same job are placed in one job per type.
+	type jobKey struct{ name, jobType string }
+	jobsByKey := make(map[jobKey]*jobPlan)
+	var jobs []*jobPlan
+	for _, group := range groups {
+		jobType, err := groupJobType(dc, group)
+		if err != nil {
+			return nil, err
+		}
+
+		var jobName string
+		switch opts.Split {
+		case SplitNone:
+			jobName = defaultName
+		case SplitService:
+			jobName = group.name
+		case SplitLabel:
+			jobName = serviceJobLabel(dc.Services[group.name])
+			if jobName == "" {
+				jobName = defaultName
+			} else if err := checkJobID(jobName); err != nil {
+				return nil, fmt.Errorf("service '%s' has an invalid %s %q: %w", group.name, jobLabel, jobName, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown split mode %q", opts.Split)
+		}
+
+		key := jobKey{name: jobName, jobType: jobType}
+		job, ok := jobsByKey[key]
+		if !ok {
+			job = &jobPlan{name: jobName, jobType: jobType}
+			jobsByKey[key] = job
+			jobs = append(jobs, job)
+		}
+		job.groups = append(job.groups, group)
+	}
+	nameJobsByType(jobs)
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].name < jobs[j].name })
+	return jobs, nil
+}
+
+// defaultJobNameFor returns the name of the job services are placed in unless
+// split off: the configured job name, else the compose project name.
+func defaultJobNameFor(dc *dockercompose.DockerCompose, opts Options) string {
+	if opts.JobName != "" {
+		return opts.JobName
+	}
+	if dc.Name != "" {
+		return dc.Name
+	}
+	return defaultJobName
+}
+
+// checkJobID reports whether name is usable as a Nomad job ID. Nomad rejects
+// empty IDs and IDs with whitespace or null characters; the ID also names the job
+// file, so path separators and relative path components are rejected too.
+func checkJobID(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("job ID is empty")
+	case strings.ContainsAny(name, " \t\n"):
+		return fmt.Errorf("job ID contains whitespace")
+	case strings.ContainsRune(name, 0):
+		return fmt.Errorf("job ID contains a null character")
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("job ID contains a path separator")
+	case name == "." || name == "..":
+		return fmt.Errorf("job ID is a relative path component")
+	}
+	return nil
+}
+
+// serviceJobLabel returns the job requested by a service through the
+// `x-nomad-job` extension key or label.
+func serviceJobLabel(service dockercompose.Service) string {
+	if service.XNomadJob != "" {
+		return service.XNomadJob
+	}
+	return parseKeyValues(service.Labels)[jobLabel]
+}
+
+// dependsOnNames returns the services named in a `depends_on` section, which
+// can be a list of names or a map of name to condition.
+func dependsOnNames(raw any) []string {
+	var names []string
+	switch typed := raw.(type) {
+	case []any:
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				names = append(names, s)
+			}
+		}
+	case map[string]any:
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	return names
+}
@@ -0,0 +1,131 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const splitDockerComposeYAML = `
+name: shop
+services:
+  web:
+    image: nginx:latest
+    ports:
+      - "80:80"
+    depends_on:
+      - api
+  api:
+    image: myapi:1.0
+    ports:
+      - "3000"
+    labels:
+      x-nomad-job: backend
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    image: postgres:16
+    x-nomad-job: backend
+`
+
+func TestConvertToNomadJobs_NoSplit(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(splitDockerComposeYAML, converter.Options{})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput, ok := files["shop.nomad.hcl"]
+	if len(files) != 1 || !ok {
+		t.Fatalf("Expected a single file named after the compose project, got %v", fileNames(files))
+	}
+	for _, group := range []string{"web", "api", "db"} {
+		if !strings.Contains(hclOutput, `group "`+group+`"`) {
+			t.Errorf("HCL output does not contain group block for '%s' service", group)
+		}
+	}
+	if strings.Contains(hclOutput, "service discovery") {
+		t.Errorf("Expected no cross-job dependency notes when all services share a job")
+	}
+}
+
+func TestConvertToNomadJobs_SplitService(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(splitDockerComposeYAML, converter.Options{Split: converter.SplitService})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected one file per service, got %v", fileNames(files))
+	}
+	web := files["web.nomad.hcl"]
+	if !strings.Contains(web, `job "web"`) || strings.Contains(web, `group "api"`) {
+		t.Errorf("Expected web job to contain only the web group:\n%s", web)
+	}
+	if !strings.Contains(web, `Depends on 'api' from job 'api'`) {
+		t.Errorf("Expected web job to note its dependency on the api job:\n%s", web)
+	}
+	apiServicePattern := `service\s*\{\s*name\s*=\s*"api"\s*port\s*=\s*"port_3000"\s*provider\s*=\s*"nomad"\s*\}`
+	if !regexp.MustCompile(apiServicePattern).MatchString(files["api.nomad.hcl"]) {
+		t.Errorf("Expected api job to register a discoverable service:\n%s", files["api.nomad.hcl"])
+	}
+}
+
+func TestConvertToNomadJobs_SplitLabel(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(splitDockerComposeYAML, converter.Options{Split: converter.SplitLabel})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected a backend job and the default job, got %v", fileNames(files))
+	}
+	backend := files["backend.nomad.hcl"]
+	if !strings.Contains(backend, `group "api"`) || !strings.Contains(backend, `group "db"`) {
+		t.Errorf("Expected backend job to contain the api and db groups:\n%s", backend)
+	}
+	if strings.Contains(backend, "service discovery") {
+		t.Errorf("Expected no cross-job note for dependencies inside the same job:\n%s", backend)
+	}
+	if !strings.Contains(files["shop.nomad.hcl"], `group "web"`) {
+		t.Errorf("Expected unlabelled services to stay in the default job")
+	}
+}
+
+func TestConvertToNomadJobs_UnknownSplit(t *testing.T) {
+	_, err := converter.ConvertToNomadJobs(splitDockerComposeYAML, converter.Options{Split: "host"})
+	if err == nil || !strings.Contains(err.Error(), "unknown split mode") {
+		t.Errorf("Expected unknown split mode error, but got: %v", err)
+	}
+}
+
+func TestConvertToNomadJobs_InvalidJobIDs(t *testing.T) {
+	tests := map[string]string{
+		"../../etc/cron.d/x": "path separator",
+		"..":                 "relative path component",
+		"my job":             "whitespace",
+		"a\x00b":             "null character",
+	}
+	for jobID, expected := range tests {
+		yaml := fmt.Sprintf("services:\n  web:\n    image: web\n    x-nomad-job: %q\n", jobID)
+		_, err := converter.ConvertToNomadJobs(yaml, converter.Options{Split: converter.SplitLabel})
+		if err == nil || !strings.Contains(err.Error(), "service 'web' has an invalid x-nomad-job") || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an invalid x-nomad-job error for %q containing %q, got %v", jobID, expected, err)
+		}
+	}
+
+	_, err := converter.ConvertToNomadJobs("name: ../shop\nservices:\n  web:\n    image: web\n", converter.Options{})
+	if err == nil || !strings.Contains(err.Error(), `invalid job name "../shop"`) {
+		t.Errorf("Expected an invalid job name error, got %v", err)
+	}
+}
+
+func fileNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	return names
+}
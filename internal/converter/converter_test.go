@@ -121,9 +121,9 @@ func TestConvertToNomadHCL_Basic(t *testing.T) {
 		t.Errorf("HCL output does not contain correct environment variable PGID for 'api' service")
 	}
 
-	webRestartPattern := `group "web"[\s\S]*?restart\s*\{\s*attempts\s*=\s*0\s*delay\s*=\s*"15s"\s*mode\s*=\s*"delay"\s*\}`
+	webRestartPattern := `group "web"[\s\S]*?restart\s*\{\s*attempts\s*=\s*3\s*interval\s*=\s*"1m"\s*mode\s*=\s*"delay"\s*\}`
 	if !regexp.MustCompile(webRestartPattern).MatchString(hclOutput) {
-		t.Errorf("HCL output does not contain correct restart policy for 'web' service (unless-stopped: attempts=0, delay=15s, mode=delay)")
+		t.Errorf("HCL output does not contain correct restart policy for 'web' service (unless-stopped: attempts=3, interval=1m, mode=delay)")
 	}
 	apiRestartPattern := `group "api"[\s\S]*?restart\s*\{\s*attempts\s*=\s*3\s*interval\s*=\s*"1m"\s*mode\s*=\s*"fail"\s*\}`
 	if !regexp.MustCompile(apiRestartPattern).MatchString(hclOutput) {
@@ -0,0 +1,90 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// traefikLabelPrefix prefixes the labels Traefik reads its dynamic
+// configuration from.
+const traefikLabelPrefix = "traefik."
+
+// traefikServerPortPattern matches the label setting the container port a
+// Traefik service forwards to.
+var traefikServerPortPattern = regexp.MustCompile(`^traefik\.(?:http|tcp|udp)\.services\.[^.]+\.loadbalancer\.server\.port$`)
+
+// isTraefikLabel reports whether a label configures Traefik.
+func isTraefikLabel(key string) bool {
+	return strings.HasPrefix(key, traefikLabelPrefix)
+}
+
+// traefikRouting is the service registration derived from Traefik labels.
+type traefikRouting struct {
+	tags          []string // "key=value" service tags
+	containerPort string   // container port Traefik forwards to; empty when unset
+	notes         []string
+}
+
+// groupTraefikLabels merges the Traefik labels and deploy labels of the group
+// members, as Swarm stacks set them on deploy. The labels of the owning
+// service win.
+func (r *jobRenderer) groupTraefikLabels(group *groupPlan) map[string]string {
+	labels := make(map[string]string)
+	members := group.members()
+	for i := len(members) - 1; i >= 0; i-- {
+		member := r.dc.Services[members[i]]
+		sources := []any{member.Labels}
+		if member.Deploy != nil {
+			sources = append(sources, member.Deploy.Labels)
+		}
+		for _, source := range sources {
+			for key, value := range parseKeyValues(source) {
+				if isTraefikLabel(key) {
+					labels[key] = value
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// traefikRoutingFor turns Traefik labels into service tags. Traefik reads the
+// address of a Nomad service from its registration, so the loadbalancer port
+// labels are not passed on; the first of them selects the port the service is
+// registered on instead.
+func traefikRoutingFor(labels map[string]string) traefikRouting {
+	var routing traefikRouting
+	for _, key := range sortedKeys(labels) {
+		value := labels[key]
+		if !traefikServerPortPattern.MatchString(key) {
+			routing.tags = append(routing.tags, key+"="+value)
+			continue
+		}
+		if _, err := portutils.ParseInt64ForPort(value); err != nil {
+			routing.notes = append(routing.notes, fmt.Sprintf("Dropping Traefik label %s: invalid port '%s'.", key, value))
+			continue
+		}
+		switch routing.containerPort {
+		case "":
+			routing.containerPort = value
+		case value:
+		default:
+			routing.notes = append(routing.notes, fmt.Sprintf("Traefik label %s forwards to port %s, but the service is registered on port %s; register another service for it.", key, value, routing.containerPort))
+		}
+	}
+	return routing
+}
+
+// publishesContainerPort reports whether one of the compose port mappings
+// targets the given container port.
+func publishesContainerPort(ports []string, containerPort string) bool {
+	for _, spec := range ports {
+		if containerPortOf(spec) == containerPort {
+			return true
+		}
+	}
+	return false
+}
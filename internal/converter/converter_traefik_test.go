@@ -0,0 +1,72 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const traefikDockerComposeYAML = `
+services:
+  whoami:
+    image: traefik/whoami
+    ports:
+      - "9000:9000 # metrics"
+      - "8080:80"
+    labels:
+      traefik.enable: "true"
+      traefik.http.routers.whoami.rule: Host(` + "`whoami.example.com`" + `)
+      traefik.http.services.whoami.loadbalancer.server.port: "80"
+      com.example.team: web
+  api:
+    image: api
+    deploy:
+      labels:
+        - traefik.http.routers.api.entrypoints=websecure
+        - traefik.http.services.api.loadbalancer.server.port=3000
+        - owner=api-team
+  jobs:
+    image: jobs
+    labels:
+      - traefik.enable=true
+`
+
+func TestConvertToNomadHCL_Traefik(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(traefikDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"whoami service port": `service\s*\{\s*name\s*=\s*"whoami"\s*port\s*=\s*"http"`,
+		"whoami tags":         `tags\s*=\s*\["traefik\.enable=true", "traefik\.http\.routers\.whoami\.rule=Host\(` + "`whoami\\.example\\.com`" + `\)"\]`,
+		"api dynamic port":    `port "port_3000"\s*\{\s*to\s*=\s*3000\s*\}`,
+		"api service port":    `service\s*\{\s*name\s*=\s*"api"\s*port\s*=\s*"port_3000"[^}]*tags\s*=\s*\["traefik\.http\.routers\.api\.entrypoints=websecure"\]`,
+		"api meta":            `group "api"\s*\{\s*count\s*=\s*1\s*meta\s*=\s*\{\s*owner\s*=\s*"api-team"\s*\}`,
+		"container labels":    `labels\s*=\s*\{\s*"com\.example\.team"\s*=\s*"web"\s*\}`,
+		"dropped labels":      `# Group 'jobs' publishes no port to register a service on`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Contains(hclOutput, "loadbalancer.server.port") {
+		t.Errorf("Loadbalancer port labels should select the service port instead of becoming tags:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_TraefikDeployLabelTags(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(traefikDockerComposeYAML, converter.Options{DeployLabels: converter.DeployLabelsTags})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+	if !regexp.MustCompile(`tags\s*=\s*\["owner=api-team", "traefik\.http\.routers\.api\.entrypoints=websecure"\]`).MatchString(hclOutput) {
+		t.Errorf("Deploy label tags and Traefik tags are not combined:\n%s", hclOutput)
+	}
+}
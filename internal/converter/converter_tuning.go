@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// taskTuning holds the kernel and process settings of a compose service.
+type taskTuning struct {
+	sysctls   map[string]string
+	ulimits   map[string]string // "limit" or "soft:hard"
+	shmSize   int64             // bytes
+	pidsLimit *int64
+	ipcMode   string
+	pidMode   string
+	init      bool
+}
+
+// serviceTuning collects the kernel and process settings of a compose service.
+// Values that cannot be parsed are returned as notes.
+func serviceTuning(service dockercompose.Service) (taskTuning, []string) {
+	tuning := taskTuning{
+		sysctls:   parseKeyValues(service.Sysctls),
+		ulimits:   make(map[string]string),
+		pidsLimit: service.PidsLimit,
+		ipcMode:   service.Ipc,
+		pidMode:   service.Pid,
+		init:      service.Init,
+	}
+
+	var notes []string
+	ulimits, _ := service.Ulimits.(map[string]any)
+	for name, raw := range ulimits {
+		limit, err := parseUlimit(raw)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Skipping ulimit %s: %v.", name, err))
+			continue
+		}
+		tuning.ulimits[name] = limit
+	}
+	if service.ShmSize != nil {
+		size, err := parseByteSize(service.ShmSize)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Skipping shm_size: %v.", err))
+		} else {
+			tuning.shmSize = size
+		}
+	}
+	return tuning, notes
+}
+
+// parseUlimit parses a compose ulimit given as a single limit (`nofile: 65535`)
+// or as soft and hard limits into the drivers' "soft:hard" form.
+func parseUlimit(raw any) (string, error) {
+	switch typed := raw.(type) {
+	case int:
+		return strconv.Itoa(typed), nil
+	case string:
+		if _, err := strconv.Atoi(typed); err != nil {
+			return "", fmt.Errorf("invalid limit '%s'", typed)
+		}
+		return typed, nil
+	case map[string]any:
+		soft, softOk := typed["soft"].(int)
+		hard, hardOk := typed["hard"].(int)
+		if !softOk || !hardOk {
+			return "", fmt.Errorf("soft and hard limits must be numbers, got '%v'", typed)
+		}
+		return fmt.Sprintf("%d:%d", soft, hard), nil
+	}
+	return "", fmt.Errorf("invalid limit '%v'", raw)
+}
+
+// isEmpty reports whether no tuning setting is set.
+func (t taskTuning) isEmpty() bool {
+	return len(t.sysctls) == 0 && len(t.ulimits) == 0 && t.shmSize == 0 && t.pidsLimit == nil &&
+		t.ipcMode == "" && t.pidMode == "" && !t.init
+}
+
+// addTuning writes the kernel and process settings of a service to the task config.
+func (r *jobRenderer) addTuning(configBody *hclwrite.Body, service dockercompose.Service) {
+	tuning, notes := serviceTuning(service)
+	for _, note := range notes {
+		configBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(note))
+	}
+	if !tuning.isEmpty() {
+		r.driver.setTuning(configBody, tuning)
+	}
+}
+
+// addUlimitBlock appends the `ulimit` block of the docker and podman drivers.
+func addUlimitBlock(config *hclwrite.Body, ulimits map[string]string) {
+	names := make([]string, 0, len(ulimits))
+	for name := range ulimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ulimitBody := config.AppendNewBlock("ulimit", nil).Body()
+	for _, name := range names {
+		ulimitBody.SetAttributeValue(name, cty.StringVal(ulimits[name]))
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const tuningDockerComposeYAML = `
+services:
+  search:
+    image: elasticsearch:8
+    sysctls:
+      net.core.somaxconn: 1024
+      vm.max_map_count: "262144"
+    ulimits:
+      nofile: 65535
+      memlock:
+        soft: -1
+        hard: -1
+      nproc:
+        soft: 1
+    shm_size: 2gb
+    pids_limit: 200
+    ipc: host
+    pid: host
+    init: true
+  db:
+    image: postgres
+    sysctls:
+      - net.ipv4.tcp_keepalive_time=600
+    shm_size: 256m
+`
+
+func TestConvertToNomadHCL_Tuning(t *testing.T) {
+	hclOutput, err := converter.ConvertToNomadHCL(tuningDockerComposeYAML)
+	if err != nil {
+		t.Fatalf("ConvertToNomadHCL failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"sysctl map":     `sysctl\s*=\s*\{\s*"net\.core\.somaxconn"\s*=\s*"1024"\s*"vm\.max_map_count"\s*=\s*"262144"\s*\}`,
+		"sysctl list":    `"net\.ipv4\.tcp_keepalive_time"\s*=\s*"600"`,
+		"shm_size":       `shm_size\s*=\s*2147483648`,
+		"shm_size mb":    `shm_size\s*=\s*268435456`,
+		"pids_limit":     `pids_limit\s*=\s*200`,
+		"ipc_mode":       `ipc_mode\s*=\s*"host"`,
+		"pid_mode":       `pid_mode\s*=\s*"host"`,
+		"init":           `init\s*=\s*true`,
+		"ulimits":        `ulimit\s*\{\s*memlock\s*=\s*"-1:-1"\s*nofile\s*=\s*"65535"\s*\}`,
+		"invalid ulimit": `# Skipping ulimit nproc: soft and hard limits must be numbers`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s:\n%s", name, hclOutput)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_PodmanTuning(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(tuningDockerComposeYAML, converter.Options{Driver: converter.DriverPodman})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+
+	checks := map[string]string{
+		"shm_size":    `shm_size\s*=\s*"2g"`,
+		"unsupported": `# The podman driver does not support pids_limit, ipc, pid`,
+		"ulimits":     `ulimit\s*\{\s*memlock\s*=\s*"-1:-1"`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not map %s for podman:\n%s", name, hclOutput)
+		}
+	}
+	if regexp.MustCompile(`ipc_mode|pid_mode|pids_limit\s*=`).MatchString(hclOutput) {
+		t.Errorf("HCL output should not use docker-only options with podman:\n%s", hclOutput)
+	}
+}
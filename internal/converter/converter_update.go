@@ -0,0 +1,167 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/dockercompose"
+	"github.com/justmiles/docker-compose-to-nomad/internal/portutils"
+)
+
+// Defaults of the Nomad update block the translated values are kept
+// consistent with.
+const (
+	defaultHealthyDeadline  = 5 * time.Minute
+	defaultProgressDeadline = 10 * time.Minute
+)
+
+// checkUpdateConfigs fails when the update_config or rollback_config of a
+// service has a duration that cannot be parsed or an unknown failure action
+// or order.
+func checkUpdateConfigs(dc *dockercompose.DockerCompose) error {
+	names := make([]string, 0, len(dc.Services))
+	for name := range dc.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		deploy := dc.Services[name].Deploy
+		if deploy == nil {
+			continue
+		}
+		configs := []struct {
+			key    string
+			config *dockercompose.UpdateConfig
+		}{{"update_config", deploy.UpdateConfig}, {"rollback_config", deploy.RollbackConfig}}
+		for _, entry := range configs {
+			key, config := entry.key, entry.config
+			if config == nil {
+				continue
+			}
+			if config.Parallelism != nil && *config.Parallelism < 0 {
+				return fmt.Errorf("service '%s' has a negative deploy.%s.parallelism %d", name, key, *config.Parallelism)
+			}
+			for _, field := range [][2]string{{"delay", config.Delay}, {"monitor", config.Monitor}} {
+				if field[1] == "" {
+					continue
+				}
+				if _, err := time.ParseDuration(field[1]); err != nil {
+					return fmt.Errorf("service '%s' has an invalid deploy.%s.%s '%s': %w", name, key, field[0], field[1], err)
+				}
+			}
+			switch config.FailureAction {
+			case "", "pause", "continue", "rollback":
+			default:
+				return fmt.Errorf("service '%s' has an unknown deploy.%s.failure_action %q", name, key, config.FailureAction)
+			}
+			switch config.Order {
+			case "", "stop-first", "start-first":
+			default:
+				return fmt.Errorf("service '%s' has an unknown deploy.%s.order %q", name, key, config.Order)
+			}
+		}
+	}
+	return nil
+}
+
+// addUpdate writes the deploy.update_config of the owning service as the
+// group update block. `monitor` is the time a task must run before Swarm
+// considers it updated, which is Nomad's min_healthy_time; the healthy and
+// progress deadlines are raised past it when needed. `order: start-first`
+// starts the new tasks as canaries, promoted automatically, and a rollback
+// failure action enables auto_revert. Durations and parallelism are validated
+// by checkUpdateConfigs before rendering.
+func (r *jobRenderer) addUpdate(groupBody *hclwrite.Body, group *groupPlan) {
+	deploy := r.dc.Services[group.name].Deploy
+	if deploy == nil || deploy.UpdateConfig == nil && deploy.RollbackConfig == nil {
+		return
+	}
+	note := func(format string, args ...any) {
+		groupBody.AppendUnstructuredTokens(portutils.CreateCommentTokens(fmt.Sprintf(format, args...)))
+	}
+	switch r.job.jobType {
+	case jobTypeBatch, jobTypeSysbatch:
+		note("Service '%s' runs to completion in a %s job, which has no rolling updates; deploy.update_config and rollback_config are ignored.", group.name, r.job.jobType)
+		return
+	}
+	systemJob := r.job.jobType == jobTypeSystem
+
+	update := deploy.UpdateConfig
+	if update == nil {
+		update = &dockercompose.UpdateConfig{}
+	}
+	maxParallel := 1
+	if update.Parallelism != nil {
+		maxParallel = *update.Parallelism
+		if maxParallel == 0 {
+			// Swarm updates every task at once.
+			maxParallel = 1
+			if deploy.Replicas != nil && *deploy.Replicas > 1 && !systemJob {
+				maxParallel = *deploy.Replicas
+			}
+		}
+	}
+	delay, _ := time.ParseDuration(update.Delay)
+	monitor, _ := time.ParseDuration(update.Monitor)
+	autoRevert := update.FailureAction == "rollback"
+
+	if update.Order == "start-first" && systemJob {
+		note("System jobs stop an allocation before replacing it; order start-first is ignored.")
+	}
+	if update.Monitor != "" && systemJob {
+		note("System jobs do not monitor updated allocations; monitor %s is ignored.", shortDuration(monitor))
+	}
+	if autoRevert && systemJob {
+		note("System jobs cannot revert a failed update; failure_action rollback is ignored.")
+		autoRevert = false
+	}
+	if delay > 0 && !systemJob {
+		note("stagger only paces system jobs; service deployments update the next allocations once the previous ones are healthy.")
+	}
+	if update.FailureAction == "continue" {
+		note("Nomad stops a deployment when an allocation fails; failure_action continue is not reproduced.")
+	}
+	if update.MaxFailureRatio > 0 {
+		note("max_failure_ratio %s has no Nomad equivalent; a deployment fails with its first unhealthy allocation.", strconv.FormatFloat(update.MaxFailureRatio, 'f', -1, 64))
+	}
+	if deploy.RollbackConfig != nil && !systemJob {
+		if autoRevert {
+			note("Nomad reverts a failed deployment with the update strategy of the job; deploy.rollback_config is not reproduced.")
+		} else {
+			note("deploy.rollback_config only applies to manual rollbacks without failure_action rollback; revert with `nomad job revert`.")
+		}
+	}
+
+	updateBlock := groupBody.AppendNewBlock("update", nil)
+	updateBody := updateBlock.Body()
+	if update.Parallelism != nil {
+		updateBody.SetAttributeValue("max_parallel", cty.NumberIntVal(int64(maxParallel)))
+	}
+	if update.Order == "start-first" && !systemJob {
+		updateBody.SetAttributeValue("canary", cty.NumberIntVal(int64(maxParallel)))
+		updateBody.SetAttributeValue("auto_promote", cty.True)
+	}
+	if delay > 0 {
+		updateBody.SetAttributeValue("stagger", cty.StringVal(shortDuration(delay)))
+	}
+	if update.Monitor != "" && !systemJob {
+		updateBody.SetAttributeValue("min_healthy_time", cty.StringVal(shortDuration(monitor)))
+		if monitor >= defaultHealthyDeadline {
+			healthyDeadline := monitor + defaultHealthyDeadline
+			updateBody.SetAttributeValue("healthy_deadline", cty.StringVal(shortDuration(healthyDeadline)))
+			updateBody.SetAttributeValue("progress_deadline", cty.StringVal(shortDuration(healthyDeadline+defaultProgressDeadline-defaultHealthyDeadline)))
+		}
+	}
+	if autoRevert {
+		updateBody.SetAttributeValue("auto_revert", cty.True)
+	}
+	if len(updateBody.Attributes()) == 0 {
+		groupBody.RemoveBlock(updateBlock)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+const updateDockerComposeYAML = `
+services:
+  web:
+    image: web
+    deploy:
+      replicas: 4
+      update_config:
+        parallelism: 2
+        delay: 10s
+        failure_action: rollback
+        monitor: 30s
+        max_failure_ratio: 0.25
+        order: start-first
+      rollback_config:
+        parallelism: 1
+  api:
+    image: api
+    deploy:
+      replicas: 3
+      update_config:
+        parallelism: 0
+        monitor: 10m
+        failure_action: continue
+  agent:
+    image: agent
+    deploy:
+      mode: global
+      update_config:
+        parallelism: 1
+        delay: 30s
+        order: start-first
+`
+
+func TestConvertToNomadJobs_Update(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(updateDockerComposeYAML, converter.Options{JobName: "update"})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+
+	checks := map[string]map[string]string{
+		"update.nomad.hcl": {
+			"start-first canaries": `update \{\s*max_parallel\s*=\s*2\s*canary\s*=\s*2\s*auto_promote\s*=\s*true\s*stagger\s*=\s*"10s"\s*min_healthy_time\s*=\s*"30s"\s*auto_revert\s*=\s*true\s*\}`,
+			"failure ratio note":   `# max_failure_ratio 0\.25 has no Nomad equivalent`,
+			"rollback config note": `# Nomad reverts a failed deployment with the update strategy of the job; deploy\.rollback_config is not reproduced\.`,
+			"all at once":          `update \{\s*max_parallel\s*=\s*3\s*min_healthy_time\s*=\s*"10m"\s*healthy_deadline\s*=\s*"15m"\s*progress_deadline\s*=\s*"20m"\s*\}`,
+			"continue note":        `# Nomad stops a deployment when an allocation fails; failure_action continue is not reproduced\.`,
+		},
+		"update-system.nomad.hcl": {
+			"system update":    `update \{\s*max_parallel\s*=\s*1\s*stagger\s*=\s*"30s"\s*\}`,
+			"start-first note": `# System jobs stop an allocation before replacing it; order start-first is ignored\.`,
+		},
+	}
+	for fileName, patterns := range checks {
+		for name, pattern := range patterns {
+			if !regexp.MustCompile(pattern).MatchString(files[fileName]) {
+				t.Errorf("%s does not map %s:\n%s", fileName, name, files[fileName])
+			}
+		}
+	}
+	if strings.Contains(files["update-system.nomad.hcl"], "canary") {
+		t.Errorf("System jobs should not have canaries:\n%s", files["update-system.nomad.hcl"])
+	}
+}
+
+func TestConvertToNomadJobs_UpdateInvalid(t *testing.T) {
+	tests := map[string]string{
+		"services:\n  web:\n    image: web\n    deploy:\n      update_config:\n        delay: soon\n":           "invalid deploy.update_config.delay 'soon'",
+		"services:\n  web:\n    image: web\n    deploy:\n      rollback_config:\n        order: random\n":       `unknown deploy.rollback_config.order "random"`,
+		"services:\n  web:\n    image: web\n    deploy:\n      update_config:\n        failure_action: retry\n": `unknown deploy.update_config.failure_action "retry"`,
+		"services:\n  web:\n    image: web\n    deploy:\n      update_config:\n        parallelism: -1\n":       "negative deploy.update_config.parallelism -1",
+		"services:\n  web:\n    image: web\n    deploy:\n      rollback_config:\n        parallelism: -2\n":     "negative deploy.rollback_config.parallelism -2",
+	}
+	for input, expected := range tests {
+		_, err := converter.ConvertToNomadJobs(input, converter.Options{})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+}
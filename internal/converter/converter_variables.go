@@ -0,0 +1,98 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// VariableOptions selects the job values lifted into top-level HCL2
+// `variable` blocks, referenced as `var.<name>` in the job. The compose values
+// are written to a separate `<job>.vars.hcl` file for `nomad job run -var-file`.
+type VariableOptions struct {
+	// ImageTags lifts the tag of every task image, e.g. `var.web_image_tag`.
+	ImageTags bool
+	// Counts lifts the count of every group, e.g. `var.web_count`.
+	Counts bool
+	// Datacenters lifts the job datacenters into `var.datacenters`.
+	Datacenters bool
+	// EnvPattern lifts every environment variable whose name matches this
+	// regular expression, e.g. `var.web_env_database_url`.
+	EnvPattern string
+}
+
+// enabled reports whether any value is lifted into a variable.
+func (v VariableOptions) enabled() bool {
+	return v.ImageTags || v.Counts || v.Datacenters || v.EnvPattern != ""
+}
+
+// params returns the job parameters lifting the selected values into HCL2 variables.
+func (v VariableOptions) params() (*jobParams, error) {
+	if !v.enabled() {
+		return nil, nil
+	}
+	var envPattern *regexp.Regexp
+	if v.EnvPattern != "" {
+		var err error
+		envPattern, err = regexp.Compile(v.EnvPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid environment variable pattern: %w", err)
+		}
+	}
+	lift := func(kind paramKind, attr string) bool {
+		switch kind {
+		case paramImageTag:
+			return v.ImageTags
+		case paramCount:
+			return v.Counts
+		case paramDatacenters:
+			return v.Datacenters
+		case paramEnv:
+			return envPattern != nil && envPattern.MatchString(attr)
+		}
+		return false
+	}
+	return &jobParams{root: "var", lift: lift, declare: true}, nil
+}
+
+// splitImageTag splits an image reference into the part before its tag and the
+// tag itself, defaulting to "latest". Images pinned by digest are not split.
+func splitImageTag(image string) (string, string, bool) {
+	if image == "" || strings.Contains(image, "@") {
+		return "", "", false
+	}
+	// A colon before the last slash separates a registry host from its port.
+	nameStart := strings.LastIndex(image, "/") + 1
+	if i := strings.LastIndex(image[nameStart:], ":"); i >= 0 {
+		return image[:nameStart+i+1], image[nameStart+i+1:], true
+	}
+	return image + ":", "latest", true
+}
+
+// variableDeclarations renders the `variable` blocks declaring the collected
+// variables. The values are supplied by the vars file.
+func (p *jobParams) variableDeclarations() []byte {
+	hclFile := hclwrite.NewEmptyFile()
+	rootBody := hclFile.Body()
+	for _, variable := range p.variables {
+		addVariableBlock(rootBody, variable, false)
+		rootBody.AppendNewline()
+	}
+	return hclFile.Bytes()
+}
+
+// varsFile renders the `-var-file` content holding the compose value of every
+// collected variable. It is empty when no variable was collected.
+func (p *jobParams) varsFile() string {
+	if p == nil || len(p.variables) == 0 {
+		return ""
+	}
+	hclFile := hclwrite.NewEmptyFile()
+	rootBody := hclFile.Body()
+	for _, variable := range p.variables {
+		rootBody.SetAttributeValue(variable.name, variable.value)
+	}
+	return string(hclwrite.Format(hclFile.Bytes()))
+}
@@ -0,0 +1,135 @@
+//go:build !js
+
+package converter_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/justmiles/docker-compose-to-nomad/internal/converter"
+)
+
+// allVariables lifts every supported value into HCL2 variables.
+var allVariables = converter.VariableOptions{
+	ImageTags:   true,
+	Counts:      true,
+	Datacenters: true,
+	EnvPattern:  "^NGINX_",
+}
+
+func TestConvertToNomadJobs_Variables(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(resourcesDockerComposeYAML, converter.Options{Variables: allVariables})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput, varsOutput := files["shop.nomad.hcl"], files["shop.vars.hcl"]
+	if varsOutput == "" {
+		t.Fatalf("Expected a shop.vars.hcl file, got %v", fileNames(files))
+	}
+
+	for _, name := range []string{"datacenters", "web_count", "web_image_tag", "web_env_nginx_host", "worker_image_tag"} {
+		if !regexp.MustCompile(`variable "` + name + `" \{`).MatchString(hclOutput) {
+			t.Errorf("HCL output does not declare variable %s:\n%s", name, hclOutput)
+		}
+	}
+	if strings.Contains(hclOutput, "default") {
+		t.Errorf("Variable declarations should leave defaults to the vars file:\n%s", hclOutput)
+	}
+	checks := map[string]string{
+		"image tag":   `image\s*=\s*"nginx:\$\{var\.web_image_tag\}"`,
+		"count":       `count\s*=\s*var\.web_count`,
+		"datacenters": `datacenters\s*=\s*var\.datacenters`,
+		"env":         `NGINX_HOST\s*=\s*var\.web_env_nginx_host`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not reference the %s variable:\n%s", name, hclOutput)
+		}
+	}
+
+	for _, pattern := range []string{`web_image_tag\s*=\s*"latest"`, `worker_image_tag\s*=\s*"1\.0"`, `web_count\s*=\s*2`, `web_env_nginx_host\s*=\s*"example\.com"`} {
+		if !regexp.MustCompile(pattern).MatchString(varsOutput) {
+			t.Errorf("Vars file does not match %s:\n%s", pattern, varsOutput)
+		}
+	}
+}
+
+func TestConvertToNomadJobs_VariablesImageReferences(t *testing.T) {
+	yaml := `
+services:
+  app:
+    image: registry:5000/team/app
+  pinned:
+    image: nginx@sha256:abc
+`
+	files, err := converter.ConvertToNomadJobs(yaml, converter.Options{Variables: converter.VariableOptions{ImageTags: true}})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput := files["my-docker-compose-job.nomad.hcl"]
+	if !strings.Contains(hclOutput, `"registry:5000/team/app:${var.app_image_tag}"`) {
+		t.Errorf("HCL output does not lift the default tag of a registry image:\n%s", hclOutput)
+	}
+	if !strings.Contains(hclOutput, `image = "nginx@sha256:abc"`) {
+		t.Errorf("HCL output should keep images pinned by digest:\n%s", hclOutput)
+	}
+}
+
+func TestConvertToNomadJobs_VariablesJSON(t *testing.T) {
+	files, err := converter.ConvertToNomadJobs(resourcesDockerComposeYAML, converter.Options{Format: converter.FormatJSON, Variables: allVariables})
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	if jsonOutput := files["shop.json"]; !strings.Contains(jsonOutput, `"Image": "nginx:latest"`) && !strings.Contains(jsonOutput, `"image": "nginx:latest"`) {
+		t.Errorf("JSON output does not resolve the image tag variable:\n%s", jsonOutput)
+	}
+}
+
+func TestConvertToNomadJobs_InvalidEnvPattern(t *testing.T) {
+	_, err := converter.ConvertToNomadJobs(resourcesDockerComposeYAML, converter.Options{Variables: converter.VariableOptions{EnvPattern: "("}})
+	if err == nil || !strings.Contains(err.Error(), "invalid environment variable pattern") {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestConvertToNomadJobs_VariableNameCollisions(t *testing.T) {
+	yaml := `
+name: shop
+services:
+  my-web:
+    image: web:1.0
+    environment:
+      DB-HOST: a
+      DB_HOST: b
+  my_web:
+    image: web:2.0
+  1web:
+    image: web:3.0
+`
+	opts := converter.Options{Lint: true, Variables: converter.VariableOptions{ImageTags: true, EnvPattern: "^DB"}}
+	files, err := converter.ConvertToNomadJobs(yaml, opts)
+	if err != nil {
+		t.Fatalf("ConvertToNomadJobs failed: %v", err)
+	}
+	hclOutput, varsOutput := files["shop.nomad.hcl"], files["shop.vars.hcl"]
+
+	checks := map[string]string{
+		"first env var":       `DB-HOST\s*=\s*var\.my_web_env_db_host\n`,
+		"colliding env var":   `DB_HOST\s*=\s*var\.my_web_env_db_host_2\n`,
+		"first service":       `task "my-web"[\s\S]*?"web:\$\{var\.my_web_image_tag\}"`,
+		"colliding service":   `task "my_web"[\s\S]*?"web:\$\{var\.my_web_image_tag_2\}"`,
+		"digit-leading name":  `task "1web"[\s\S]*?"web:\$\{var\._1web_image_tag\}"`,
+		"declared collisions": `variable "my_web_env_db_host_2" \{`,
+	}
+	for name, pattern := range checks {
+		if !regexp.MustCompile(pattern).MatchString(hclOutput) {
+			t.Errorf("HCL output does not match the %s:\n%s", name, hclOutput)
+		}
+	}
+	for _, pattern := range []string{`my_web_env_db_host\s*=\s*"a"`, `my_web_env_db_host_2\s*=\s*"b"`, `my_web_image_tag\s*=\s*"1\.0"`, `my_web_image_tag_2\s*=\s*"2\.0"`, `_1web_image_tag\s*=\s*"3\.0"`} {
+		if !regexp.MustCompile(pattern).MatchString(varsOutput) {
+			t.Errorf("Vars file does not match %s:\n%s", pattern, varsOutput)
+		}
+	}
+}
@@ -2,24 +2,130 @@ package dockercompose
 
 // DockerCompose represents the top-level structure of a docker-compose.yml file.
 type DockerCompose struct {
-	Version  string             `yaml:"version"`
-	Services map[string]Service `yaml:"services"`
-	Volumes  map[string]any     `yaml:"volumes"` // Keep as any for now, can be more specific if needed
+	Version  string             `yaml:"version,omitempty"`
+	Name     string             `yaml:"name,omitempty"` // Compose project name
+	Services map[string]Service `yaml:"services,omitempty"`
+	Volumes  map[string]any     `yaml:"volumes,omitempty"` // Keep as any for now, can be more specific if needed
+	Networks map[string]Network `yaml:"networks,omitempty"`
+}
+
+// Network represents a top-level network definition.
+type Network struct {
+	Name     string `yaml:"name,omitempty"`
+	Driver   string `yaml:"driver,omitempty"` // e.g. "bridge", "overlay" or "macvlan"
+	Internal bool   `yaml:"internal,omitempty"`
+	External any    `yaml:"external,omitempty"` // Can be a bool or, in older files, a map with a name
 }
 
 // Service represents a single service defined in docker-compose.yml.
 type Service struct {
-	Image       string            `yaml:"image"`
-	Ports       []string          `yaml:"ports"`
-	Environment any               `yaml:"environment"` // Can be map[string]string or []string
-	Volumes     []string          `yaml:"volumes"`
-	Command     any               `yaml:"command"`    // Can be string or list
-	Entrypoint  any               `yaml:"entrypoint"` // Can be string or list
-	Restart     string            `yaml:"restart"`
-	Deploy      *Deploy           `yaml:"deploy"`
+	Image           string   `yaml:"image,omitempty"`
+	Ports           []string `yaml:"ports,omitempty"`
+	Environment     any      `yaml:"environment,omitempty"` // Can be map[string]string or []string
+	Volumes         []string `yaml:"volumes,omitempty"`
+	Command         any      `yaml:"command,omitempty"`    // Can be string or list
+	Entrypoint      any      `yaml:"entrypoint,omitempty"` // Can be string or list
+	Restart         string   `yaml:"restart,omitempty"`
+	Deploy          *Deploy  `yaml:"deploy,omitempty"`
+	Labels          any      `yaml:"labels,omitempty"`          // Can be map[string]string or []string
+	DependsOn       any      `yaml:"depends_on,omitempty"`      // Can be a list of names or a map of name to condition
+	Links           []string `yaml:"links,omitempty"`           // "service" or "service:alias"
+	XNomadJob       string   `yaml:"x-nomad-job,omitempty"`     // Name of the Nomad job this service is placed in when splitting by label
+	XNomadOneshot   *bool    `yaml:"x-nomad-oneshot,omitempty"` // Whether the service runs to completion, overriding the detection
+	NetworkMode     string   `yaml:"network_mode,omitempty"`    // e.g. "service:vpn" or "container:vpn"
+	Networks        any      `yaml:"networks,omitempty"`        // Can be a list of names or a map of name to aliases and addresses
+	ContainerName   string   `yaml:"container_name,omitempty"`
+	Cpus            any      `yaml:"cpus,omitempty"`            // Number of CPUs, as string or number
+	MemLimit        any      `yaml:"mem_limit,omitempty"`       // Byte size, e.g. "512m"
+	MemReservation  any      `yaml:"mem_reservation,omitempty"` // Byte size, e.g. "256m"
+	User            string   `yaml:"user,omitempty"`
+	Privileged      bool     `yaml:"privileged,omitempty"`
+	CapAdd          []string `yaml:"cap_add,omitempty"`
+	CapDrop         []string `yaml:"cap_drop,omitempty"`
+	Devices         []any    `yaml:"devices,omitempty"` // "host[:container[:permissions]]" strings or source/target/permissions maps
+	SecurityOpt     []string `yaml:"security_opt,omitempty"`
+	ReadOnly        bool     `yaml:"read_only,omitempty"`
+	GroupAdd        []any    `yaml:"group_add,omitempty"` // Group names or IDs
+	UsernsMode      string   `yaml:"userns_mode,omitempty"`
+	Sysctls         any      `yaml:"sysctls,omitempty"`  // Can be map[string]any or []string of "key=value"
+	Ulimits         any      `yaml:"ulimits,omitempty"`  // Map of name to a limit number or a map with soft and hard keys
+	ShmSize         any      `yaml:"shm_size,omitempty"` // Byte size, e.g. "2gb"
+	PidsLimit       *int64   `yaml:"pids_limit,omitempty"`
+	Ipc             string   `yaml:"ipc,omitempty"`
+	Pid             string   `yaml:"pid,omitempty"`
+	Init            bool     `yaml:"init,omitempty"`
+	Hostname        string   `yaml:"hostname,omitempty"`
+	Domainname      string   `yaml:"domainname,omitempty"`
+	MacAddress      string   `yaml:"mac_address,omitempty"`
+	DNS             any      `yaml:"dns,omitempty"`        // Can be string or list
+	DNSSearch       any      `yaml:"dns_search,omitempty"` // Can be string or list
+	DNSOpt          []string `yaml:"dns_opt,omitempty"`
+	ExtraHosts      any      `yaml:"extra_hosts,omitempty"` // Can be a list of "host:ip" / "host=ip" or a map of host to IP
+	StopSignal      string   `yaml:"stop_signal,omitempty"`
+	StopGracePeriod string   `yaml:"stop_grace_period,omitempty"` // Duration, e.g. "1m30s"
+	Logging         *Logging `yaml:"logging,omitempty"`
+}
+
+// Logging represents the logging configuration of a service.
+type Logging struct {
+	Driver  string         `yaml:"driver,omitempty"`
+	Options map[string]any `yaml:"options,omitempty"` // Driver options, e.g. max-size
 }
 
 // Deploy represents the deployment configuration for a service.
 type Deploy struct {
-	Replicas *int `yaml:"replicas"`
-}
\ No newline at end of file
+	Mode      string     `yaml:"mode,omitempty"` // "replicated" (default), "global", "replicated-job" or "global-job"
+	Replicas  *int       `yaml:"replicas,omitempty"`
+	Resources *Resources `yaml:"resources,omitempty"`
+	Labels    any        `yaml:"labels,omitempty"` // Can be map[string]string or []string
+	Placement *Placement `yaml:"placement,omitempty"`
+
+	UpdateConfig   *UpdateConfig  `yaml:"update_config,omitempty"`
+	RollbackConfig *UpdateConfig  `yaml:"rollback_config,omitempty"`
+	RestartPolicy  *RestartPolicy `yaml:"restart_policy,omitempty"`
+}
+
+// RestartPolicy represents when and how often Swarm replaces the exited
+// tasks of a deployed service.
+type RestartPolicy struct {
+	Condition   string `yaml:"condition,omitempty"` // "none", "on-failure" or "any" (default)
+	Delay       string `yaml:"delay,omitempty"`
+	MaxAttempts *int   `yaml:"max_attempts,omitempty"` // 0 or unset retries forever
+	Window      string `yaml:"window,omitempty"`
+}
+
+// UpdateConfig represents how the tasks of a deployed service are updated,
+// or rolled back.
+type UpdateConfig struct {
+	Parallelism     *int    `yaml:"parallelism,omitempty"` // 0 updates all tasks at once
+	Delay           string  `yaml:"delay,omitempty"`
+	FailureAction   string  `yaml:"failure_action,omitempty"` // "pause" (default), "continue" or "rollback"
+	Monitor         string  `yaml:"monitor,omitempty"`
+	MaxFailureRatio float64 `yaml:"max_failure_ratio,omitempty"`
+	Order           string  `yaml:"order,omitempty"` // "stop-first" (default) or "start-first"
+}
+
+// Placement represents the Swarm scheduling rules of a deployed service.
+type Placement struct {
+	Constraints []string              `yaml:"constraints,omitempty"` // e.g. "node.labels.zone==east"
+	Preferences []PlacementPreference `yaml:"preferences,omitempty"`
+	MaxReplicas *int                  `yaml:"max_replicas_per_node,omitempty"`
+}
+
+// PlacementPreference spreads the tasks of a service across the values of a
+// node attribute.
+type PlacementPreference struct {
+	Spread string `yaml:"spread,omitempty"`
+}
+
+// Resources represents the resource constraints of a deployed service.
+type Resources struct {
+	Limits       *ResourceSpec `yaml:"limits,omitempty"`
+	Reservations *ResourceSpec `yaml:"reservations,omitempty"`
+}
+
+// ResourceSpec represents a set of resource limits or reservations.
+type ResourceSpec struct {
+	Cpus   any `yaml:"cpus,omitempty"`   // Number of CPUs, as string or number
+	Memory any `yaml:"memory,omitempty"` // Byte size, e.g. "512M"
+}